@@ -23,6 +23,14 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ThumbnailServiceClient interface {
 	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error)
+	// Availability and metadata of variants without image data.
+	Head(ctx context.Context, in *HeadRequest, opts ...grpc.CallOption) (*HeadResponse, error)
+	// Videos with perceptually similar cached thumbnails, e.g. reuploads.
+	FindSimilar(ctx context.Context, in *FindSimilarRequest, opts ...grpc.CallOption) (*FindSimilarResponse, error)
+	// Thumbnails of several videos composed into one image.
+	Mosaic(ctx context.Context, in *MosaicRequest, opts ...grpc.CallOption) (*MosaicResponse, error)
+	// Frame captures of a video generated by YouTube, e.g. hq1.jpg.
+	GetFrames(ctx context.Context, in *GetFramesRequest, opts ...grpc.CallOption) (*GetFramesResponse, error)
 }
 
 type thumbnailServiceClient struct {
@@ -42,11 +50,55 @@ func (c *thumbnailServiceClient) Get(ctx context.Context, in *GetRequest, opts .
 	return out, nil
 }
 
+func (c *thumbnailServiceClient) Head(ctx context.Context, in *HeadRequest, opts ...grpc.CallOption) (*HeadResponse, error) {
+	out := new(HeadResponse)
+	err := c.cc.Invoke(ctx, "/ThumbnailService/Head", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *thumbnailServiceClient) FindSimilar(ctx context.Context, in *FindSimilarRequest, opts ...grpc.CallOption) (*FindSimilarResponse, error) {
+	out := new(FindSimilarResponse)
+	err := c.cc.Invoke(ctx, "/ThumbnailService/FindSimilar", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *thumbnailServiceClient) Mosaic(ctx context.Context, in *MosaicRequest, opts ...grpc.CallOption) (*MosaicResponse, error) {
+	out := new(MosaicResponse)
+	err := c.cc.Invoke(ctx, "/ThumbnailService/Mosaic", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *thumbnailServiceClient) GetFrames(ctx context.Context, in *GetFramesRequest, opts ...grpc.CallOption) (*GetFramesResponse, error) {
+	out := new(GetFramesResponse)
+	err := c.cc.Invoke(ctx, "/ThumbnailService/GetFrames", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ThumbnailServiceServer is the server API for ThumbnailService service.
 // All implementations must embed UnimplementedThumbnailServiceServer
 // for forward compatibility
 type ThumbnailServiceServer interface {
 	Get(context.Context, *GetRequest) (*GetResponse, error)
+	// Availability and metadata of variants without image data.
+	Head(context.Context, *HeadRequest) (*HeadResponse, error)
+	// Videos with perceptually similar cached thumbnails, e.g. reuploads.
+	FindSimilar(context.Context, *FindSimilarRequest) (*FindSimilarResponse, error)
+	// Thumbnails of several videos composed into one image.
+	Mosaic(context.Context, *MosaicRequest) (*MosaicResponse, error)
+	// Frame captures of a video generated by YouTube, e.g. hq1.jpg.
+	GetFrames(context.Context, *GetFramesRequest) (*GetFramesResponse, error)
 	mustEmbedUnimplementedThumbnailServiceServer()
 }
 
@@ -57,6 +109,18 @@ type UnimplementedThumbnailServiceServer struct {
 func (UnimplementedThumbnailServiceServer) Get(context.Context, *GetRequest) (*GetResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
 }
+func (UnimplementedThumbnailServiceServer) Head(context.Context, *HeadRequest) (*HeadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Head not implemented")
+}
+func (UnimplementedThumbnailServiceServer) FindSimilar(context.Context, *FindSimilarRequest) (*FindSimilarResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindSimilar not implemented")
+}
+func (UnimplementedThumbnailServiceServer) Mosaic(context.Context, *MosaicRequest) (*MosaicResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Mosaic not implemented")
+}
+func (UnimplementedThumbnailServiceServer) GetFrames(context.Context, *GetFramesRequest) (*GetFramesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFrames not implemented")
+}
 func (UnimplementedThumbnailServiceServer) mustEmbedUnimplementedThumbnailServiceServer() {}
 
 // UnsafeThumbnailServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -88,6 +152,78 @@ func _ThumbnailService_Get_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ThumbnailService_Head_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HeadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ThumbnailServiceServer).Head(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ThumbnailService/Head",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ThumbnailServiceServer).Head(ctx, req.(*HeadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ThumbnailService_FindSimilar_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindSimilarRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ThumbnailServiceServer).FindSimilar(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ThumbnailService/FindSimilar",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ThumbnailServiceServer).FindSimilar(ctx, req.(*FindSimilarRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ThumbnailService_Mosaic_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MosaicRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ThumbnailServiceServer).Mosaic(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ThumbnailService/Mosaic",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ThumbnailServiceServer).Mosaic(ctx, req.(*MosaicRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ThumbnailService_GetFrames_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetFramesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ThumbnailServiceServer).GetFrames(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ThumbnailService/GetFrames",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ThumbnailServiceServer).GetFrames(ctx, req.(*GetFramesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ThumbnailService_ServiceDesc is the grpc.ServiceDesc for ThumbnailService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -99,6 +235,22 @@ var ThumbnailService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Get",
 			Handler:    _ThumbnailService_Get_Handler,
 		},
+		{
+			MethodName: "Head",
+			Handler:    _ThumbnailService_Head_Handler,
+		},
+		{
+			MethodName: "FindSimilar",
+			Handler:    _ThumbnailService_FindSimilar_Handler,
+		},
+		{
+			MethodName: "Mosaic",
+			Handler:    _ThumbnailService_Mosaic_Handler,
+		},
+		{
+			MethodName: "GetFrames",
+			Handler:    _ThumbnailService_GetFrames_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/thumbnail_v1/thumbnail.proto",
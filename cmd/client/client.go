@@ -6,6 +6,8 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"log/slog"
+	"net"
 	"os"
 	"path"
 	"strings"
@@ -15,10 +17,13 @@ import (
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
 	pb "github.com/pegov/yt-thumbnails-go/api/thumbnail_v1"
+	"github.com/pegov/yt-thumbnails-go/internal/tlsconfig"
 )
 
 var (
@@ -28,6 +33,34 @@ var (
 	async               = flag.Bool("async", false, "make requests in parallel")
 	maxParallelRequests = flag.Int("max-parallel-requests", 8, "max parallel requests")
 	maxRetries          = flag.Int("max-retries", 3, "max retries if service is unavailable (0 - no retries)")
+	apiKey              = flag.String("api-key", "", "API key sent as x-api-key metadata")
+	noCache             = flag.Bool("no-cache", false, "download again and refresh server cache")
+	onlyIfCached        = flag.Bool("only-if-cached", false, "fail instead of downloading if not cached")
+	maxAge              = flag.Int64("max-age", 0, "accept only cached thumbnails younger than N seconds (0 - server TTL)")
+	width               = flag.Int("width", 0, "resize to width (0 - keep aspect ratio)")
+	height              = flag.Int("height", 0, "resize to height (0 - keep aspect ratio)")
+	fit                 = flag.String("fit", "contain", "resize mode if both width and height are set: contain, cover, fill")
+	cropLetterbox       = flag.Bool("crop-letterbox", false, "crop black letterbox bars (unset - server default)")
+	format              = flag.String("format", "", "re-encode to jpeg or png (empty - keep original)")
+	quality             = flag.Int("quality", 0, "JPEG quality 1-100 (0 - server default)")
+	progressive         = flag.Bool("progressive", false, "progressive JPEG")
+	stripMetadata       = flag.Bool("strip-metadata", false, "remove EXIF, ICC profiles and comments")
+
+	mosaic     = flag.Bool("mosaic", false, "save all thumbnails as one mosaic image")
+	columns    = flag.Int("columns", 0, "mosaic columns (0 - square grid)")
+	rows       = flag.Int("rows", 0, "mosaic rows (0 - enough for all urls)")
+	tileWidth  = flag.Int("tile-width", 0, "mosaic tile width (0 - 320)")
+	tileHeight = flag.Int("tile-height", 0, "mosaic tile height (0 - 180)")
+	padding    = flag.Int("padding", 0, "space between mosaic tiles")
+	captions   = flag.Bool("captions", false, "draw video IDs on mosaic tiles")
+
+	frames = flag.Bool("frames", false, "save frame captures of each video instead of thumbnail")
+
+	useTLS        = flag.Bool("tls", false, "connect using TLS (implied by other --tls-* flags)")
+	tlsCA         = flag.String("tls-ca", "", "CA file to verify server (empty - system roots)")
+	tlsCert       = flag.String("tls-cert", "", "client certificate file (mTLS)")
+	tlsKey        = flag.String("tls-key", "", "client private key file (mTLS)")
+	tlsServerName = flag.String("tls-server-name", "", "override server name for certificate verification")
 )
 
 var retryPolicyTemplate = `{
@@ -43,11 +76,44 @@ var retryPolicyTemplate = `{
 	}
 }]}`
 
+func newRequest(url string) *pb.GetRequest {
+	req := &pb.GetRequest{
+		Url:          url,
+		NoCache:      *noCache,
+		OnlyIfCached: *onlyIfCached,
+		MaxAge:       *maxAge,
+		Width:        int32(*width),
+		Height:       int32(*height),
+		Fit:          pb.Fit(pb.Fit_value["FIT_"+strings.ToUpper(*fit)]),
+	}
+	if *format != "" || *quality != 0 || *progressive || *stripMetadata {
+		req.Format = &pb.Format{
+			Encoding:      pb.Encoding(pb.Encoding_value["ENCODING_"+strings.ToUpper(*format)]),
+			Quality:       int32(*quality),
+			Progressive:   *progressive,
+			StripMetadata: *stripMetadata,
+		}
+	}
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "crop-letterbox" {
+			req.CropLetterbox = cropLetterbox
+		}
+	})
+	return req
+}
+
 func main() {
 	flag.Parse()
 
 	log.SetFlags(0)
 
+	if _, ok := pb.Fit_value["FIT_"+strings.ToUpper(*fit)]; !ok {
+		log.Fatalf("%v is not a valid fit!", *fit)
+	}
+	if _, ok := pb.Encoding_value["ENCODING_"+strings.ToUpper(*format)]; *format != "" && !ok {
+		log.Fatalf("%v is not a valid format!", *format)
+	}
+
 	// Create output folder if it does not exist
 	if info, err := os.Stat(*output); !os.IsNotExist(err) {
 		if !info.IsDir() {
@@ -61,11 +127,31 @@ func main() {
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	if *apiKey != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "x-api-key", *apiKey)
+	}
+
+	creds := insecure.NewCredentials()
+	if *useTLS || *tlsCA != "" || *tlsCert != "" || *tlsServerName != "" {
+		reloader, err := tlsconfig.NewReloader(slog.Default(), *tlsCert, *tlsKey, *tlsCA)
+		if err != nil {
+			log.Fatalf("Could not load certificates: %v", err)
+		}
+		serverName := *tlsServerName
+		if serverName == "" {
+			host, _, err := net.SplitHostPort(*addr)
+			if err != nil {
+				log.Fatalf("Invalid address %v: %v", *addr, err)
+			}
+			serverName = host
+		}
+		creds = credentials.NewTLS(reloader.ClientConfig(serverName))
+	}
 
 	retryPolicy := fmt.Sprintf(retryPolicyTemplate, *maxRetries)
 	conn, err := grpc.Dial(
 		*addr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithTransportCredentials(creds),
 		grpc.WithDefaultServiceConfig(retryPolicy),
 	)
 	if err != nil {
@@ -103,6 +189,15 @@ func main() {
 
 	log.Printf("Total number of urls: %d", len(urls))
 
+	if *mosaic {
+		saveMosaic(ctx, c, urls)
+		return
+	}
+	if *frames {
+		saveFrames(ctx, c, urls)
+		return
+	}
+
 	if !*async {
 		log.Printf("async: OFF")
 
@@ -110,13 +205,13 @@ func main() {
 		for _, url := range urls {
 			ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 			defer cancel()
-			res, err := c.Get(ctx, &pb.GetRequest{Url: url})
+			res, err := c.Get(ctx, newRequest(url))
 			if err != nil {
 				logError(url, err)
 			} else {
 				b := res.GetData()
 				videoID := res.GetVideoId()
-				fullPath := writeFile(videoID, b, *output)
+				fullPath := writeFile(videoID, b, res.GetContentType(), *output)
 				log.Printf("Saved %s\n", fullPath)
 				successfullOps++
 			}
@@ -144,7 +239,7 @@ func main() {
 			semaphore <- struct{}{}
 			ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 			defer cancel()
-			res, err := c.Get(ctx, &pb.GetRequest{Url: url})
+			res, err := c.Get(ctx, newRequest(url))
 			<-semaphore
 
 			if err != nil {
@@ -152,7 +247,7 @@ func main() {
 			} else {
 				b := res.GetData()
 				videoID := res.GetVideoId()
-				fullPath := writeFile(videoID, b, *output)
+				fullPath := writeFile(videoID, b, res.GetContentType(), *output)
 				log.Printf("Saved %s\n", fullPath)
 				successfullOps.Add(1)
 			}
@@ -168,9 +263,76 @@ func main() {
 	)
 }
 
-func writeFile(videoID string, b []byte, outputPath string) string {
-	const NewFileFormat = "%s.jpg"
-	filename := fmt.Sprintf(NewFileFormat, videoID)
+func saveMosaic(ctx context.Context, c pb.ThumbnailServiceClient, urls []string) {
+	req := &pb.MosaicRequest{
+		Urls:       urls,
+		Columns:    int32(*columns),
+		Rows:       int32(*rows),
+		TileWidth:  int32(*tileWidth),
+		TileHeight: int32(*tileHeight),
+		Padding:    int32(*padding),
+		Captions:   *captions,
+	}
+	if *format != "" || *quality != 0 || *progressive {
+		req.Format = &pb.Format{
+			Encoding:    pb.Encoding(pb.Encoding_value["ENCODING_"+strings.ToUpper(*format)]),
+			Quality:     int32(*quality),
+			Progressive: *progressive,
+		}
+	}
+
+	// Server downloads all thumbnails first
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	res, err := c.Mosaic(ctx, req)
+	if err != nil {
+		log.Fatalf("Could not make mosaic: %v", err)
+	}
+	for _, url := range res.GetMissing() {
+		log.Printf("%v: not found", url)
+	}
+	fullPath := writeFile("mosaic", res.GetData(), res.GetContentType(), *output)
+	log.Printf("Saved %s (%dx%d)\n", fullPath, res.GetWidth(), res.GetHeight())
+}
+
+func saveFrames(ctx context.Context, c pb.ThumbnailServiceClient, urls []string) {
+	var successfullOps int
+	for _, url := range urls {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		res, err := c.GetFrames(ctx, &pb.GetFramesRequest{
+			Url:          url,
+			NoCache:      *noCache,
+			OnlyIfCached: *onlyIfCached,
+			MaxAge:       *maxAge,
+		})
+		if err != nil {
+			logError(url, err)
+			continue
+		}
+		for _, frame := range res.GetFrames() {
+			name := fmt.Sprintf("%s_%d", res.GetVideoId(), frame.GetIndex())
+			thumbnail := frame.GetThumbnail()
+			fullPath := writeFile(name, thumbnail.GetData(), thumbnail.GetContentType(), *output)
+			log.Printf("Saved %s\n", fullPath)
+		}
+		successfullOps++
+	}
+
+	log.Printf(
+		"Downloaded frames of %d/%d videos",
+		successfullOps,
+		len(urls),
+	)
+}
+
+func writeFile(videoID string, b []byte, contentType string, outputPath string) string {
+	const NewFileFormat = "%s.%s"
+	ext := "jpg"
+	if contentType == "image/png" {
+		ext = "png"
+	}
+	filename := fmt.Sprintf(NewFileFormat, videoID, ext)
 	p := path.Join(outputPath, filename)
 	os.WriteFile(p, b, 0666)
 	return p
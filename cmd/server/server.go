@@ -2,71 +2,280 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"expvar"
 	"flag"
+	"fmt"
 	"log/slog"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
-	"time"
 
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 
 	pb "github.com/pegov/yt-thumbnails-go/api/thumbnail_v1"
+	"github.com/pegov/yt-thumbnails-go/internal/accesslog"
+	"github.com/pegov/yt-thumbnails-go/internal/admin"
+	"github.com/pegov/yt-thumbnails-go/internal/auth"
 	"github.com/pegov/yt-thumbnails-go/internal/cache/sqlite"
+	"github.com/pegov/yt-thumbnails-go/internal/config"
 	"github.com/pegov/yt-thumbnails-go/internal/downloader"
 	"github.com/pegov/yt-thumbnails-go/internal/extractor"
+	"github.com/pegov/yt-thumbnails-go/internal/gateway"
+	"github.com/pegov/yt-thumbnails-go/internal/health"
+	"github.com/pegov/yt-thumbnails-go/internal/imaging"
+	"github.com/pegov/yt-thumbnails-go/internal/logging"
 	"github.com/pegov/yt-thumbnails-go/internal/server"
-)
-
-var (
-	addr                    = flag.String("addr", "localhost:8080", "address")
-	maxParallelHTTPRequests = flag.Int(
-		"max-parallel-http-requests",
-		16,
-		"max parallel http requests to youtube",
-	)
+	"github.com/pegov/yt-thumbnails-go/internal/tlsconfig"
+	"github.com/pegov/yt-thumbnails-go/internal/tracing"
 )
 
 func main() {
-	flag.Parse()
+	cfg, printConfig, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid config: %v\n", err)
+		os.Exit(2)
+	}
 
-	logLevel, ok := os.LookupEnv("LOG_LEVEL")
-	if !ok {
-		logLevel = "INFO"
+	if printConfig {
+		if err := cfg.Write(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Could not print config: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	var logLevel slog.LevelVar
+	logLevel.Set(parseLevel(cfg.Log.Level))
+	logOutput, err := logging.Open(cfg.Log.Output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not open log output: %v\n", err)
+		os.Exit(1)
+	}
+	defer logOutput.Close()
+	logger, err := logging.New(cfg.Log.Format, logOutput, &logLevel)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not setup logger: %v\n", err)
+		os.Exit(1)
 	}
 
-	logger := setupLogger(logLevel)
+	// Current config, swapped on SIGHUP
+	var current atomic.Pointer[config.Config]
+	current.Store(cfg)
 
 	ctx := context.Background()
 
-	ctxCache, cancel := context.WithTimeout(ctx, 5*time.Second)
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+		ServiceName: "yt-thumbnails",
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+	})
+	if err != nil {
+		logger.Error("Could not setup tracing", slog.Any("err", err))
+		os.Exit(1)
+	}
+
+	ctxCache, cancel := context.WithTimeout(ctx, cfg.Limits.RequestTimeout)
 	defer cancel()
-	sqliteCache, err := sqlite.New(ctxCache, "./thumbnail.db")
+	sqliteCache, err := sqlite.New(ctxCache, cfg.Cache.Path, cfg.Cache.TTL)
 	if err != nil {
 		logger.Error("Could not create sqlite cache", slog.Any("err", err))
 		os.Exit(1)
 	}
 
+	httpClient, err := downloader.NewHTTPClient(downloader.ClientConfig{
+		DialTimeout:           cfg.Downloader.DialTimeout,
+		TLSHandshakeTimeout:   cfg.Downloader.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: cfg.Downloader.ResponseHeaderTimeout,
+		IdleConnTimeout:       cfg.Downloader.IdleConnTimeout,
+		MaxIdleConnsPerHost:   cfg.Downloader.MaxIdleConnsPerHost,
+		HTTP2:                 cfg.Downloader.HTTP2,
+		ProxyURL:              cfg.Downloader.ProxyURL,
+	})
+	if err != nil {
+		logger.Error("Could not create upstream HTTP client", slog.Any("err", err))
+		os.Exit(1)
+	}
+	headers, err := downloader.ParseHeaders(cfg.Downloader.Headers)
+	if err != nil {
+		logger.Error("Could not parse upstream headers", slog.Any("err", err))
+		os.Exit(1)
+	}
+	if cfg.Downloader.UserAgent != "" {
+		headers.Set("User-Agent", cfg.Downloader.UserAgent)
+	}
+
+	validator := downloader.Validator{MinSize: cfg.Downloader.MinImageBytes}
+	var (
+		thumbnailDownloader server.Downloader = downloader.NewMaxResOrHqDownloader(
+			httpClient,
+			headers,
+			validator,
+			cfg.Downloader.MaxImageBytes,
+		)
+		circuit health.Circuit
+	)
+	if cfg.Downloader.CircuitBreakerThreshold > 0 {
+		cb := downloader.NewCircuitBreaker(
+			thumbnailDownloader,
+			cfg.Downloader.CircuitBreakerThreshold,
+			cfg.Downloader.CircuitBreakerCooldown,
+		)
+		thumbnailDownloader = cb
+		circuit = cb
+	}
+
 	shutdown := make(chan struct{}, 1)
 	srv := server.NewServer(
 		logger,
 		sqliteCache,
 		extractor.RegexExtractor{},
-		downloader.MaxResOrHqDownloader{},
-		*maxParallelHTTPRequests,
+		thumbnailDownloader,
+		cfg.Downloader.MaxParallelRequests,
+		cfg.Limits.RequestTimeout,
 		shutdown,
 	)
+	srv.SetCropLetterbox(cfg.Imaging.CropLetterbox)
+	srv.SetMaxParallelTransforms(cfg.Imaging.MaxParallelTransforms)
+	// Validated with config
+	sizes, _ := imaging.ParseSizes(cfg.Imaging.Sizes)
+	srv.SetAllowedSizes(sizes)
+
+	ctxWatch, cancelWatch := context.WithCancel(ctx)
+	defer cancelWatch()
+	var tlsReloader *tlsconfig.Reloader
+	serverOpts := []grpc.ServerOption{
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(logging.UnaryInterceptor(logger)),
+		grpc.ChainStreamInterceptor(logging.StreamInterceptor(logger)),
+	}
+	if cfg.AccessLog.Enabled {
+		accessLogger := logger
+		if cfg.AccessLog.Output != "" {
+			accessLogOutput := accesslog.NewRotatingFile(
+				cfg.AccessLog.Output,
+				cfg.AccessLog.MaxSizeMB,
+				cfg.AccessLog.MaxBackups,
+				cfg.AccessLog.MaxAgeDays,
+			)
+			defer accessLogOutput.Close()
+			accessLogger, err = logging.New(cfg.Log.Format, accessLogOutput, slog.LevelInfo)
+			if err != nil {
+				logger.Error("Could not setup access log", slog.Any("err", err))
+				os.Exit(1)
+			}
+		}
+		accessLog := accesslog.New(
+			accessLogger,
+			cfg.AccessLog.ErrorsOnly,
+			cfg.AccessLog.SamplePercent,
+		)
+		serverOpts = append(serverOpts, grpc.ChainUnaryInterceptor(accessLog.UnaryInterceptor()))
+	}
+	if cfg.TLS.Cert != "" {
+		tlsReloader, err = tlsconfig.NewReloader(logger, cfg.TLS.Cert, cfg.TLS.Key, cfg.TLS.ClientCA)
+		if err != nil {
+			logger.Error("Could not load certificates", slog.Any("err", err))
+			os.Exit(1)
+		}
+		go tlsReloader.Watch(ctxWatch, cfg.TLS.ReloadInterval)
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(tlsReloader.ServerConfig())))
+	}
+
+	var authenticator *auth.Authenticator
+	var keyStore *auth.Store
+	if cfg.Auth.KeysFile != "" {
+		keyStore, err = auth.NewStore(logger, cfg.Auth.KeysFile)
+		if err != nil {
+			logger.Error("Could not load API keys", slog.Any("err", err))
+			os.Exit(1)
+		}
+		go keyStore.Watch(ctxWatch, cfg.Auth.ReloadInterval)
+
+		// Load balancers and grpcurl users must work without a key
+		authenticator = auth.NewAuthenticator(keyStore, []string{
+			"/grpc.health.v1.Health/",
+			"/grpc.reflection.",
+			// Checked by admin keys below
+			"/" + pb.AdminService_ServiceDesc.ServiceName + "/",
+		})
+		serverOpts = append(
+			serverOpts,
+			grpc.ChainUnaryInterceptor(authenticator.UnaryInterceptor()),
+			grpc.ChainStreamInterceptor(authenticator.StreamInterceptor()),
+		)
+	}
+
+	var adminKeyStore *auth.Store
+	if cfg.Auth.AdminKeysFile != "" {
+		adminKeyStore, err = auth.NewStore(logger, cfg.Auth.AdminKeysFile)
+		if err != nil {
+			logger.Error("Could not load admin keys", slog.Any("err", err))
+			os.Exit(1)
+		}
+		go adminKeyStore.Watch(ctxWatch, cfg.Auth.ReloadInterval)
+
+		adminAuthenticator := auth.NewScopedAuthenticator(adminKeyStore, []string{
+			"/" + pb.AdminService_ServiceDesc.ServiceName + "/",
+		})
+		serverOpts = append(
+			serverOpts,
+			grpc.ChainUnaryInterceptor(adminAuthenticator.UnaryInterceptor()),
+			grpc.ChainStreamInterceptor(adminAuthenticator.StreamInterceptor()),
+		)
+	}
 
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(serverOpts...)
 	pb.RegisterThumbnailServiceServer(grpcServer, srv)
+	if adminKeyStore != nil {
+		pb.RegisterAdminServiceServer(grpcServer, admin.NewServer(logger, sqliteCache))
+	}
+
+	ctxHealth, cancelHealth := context.WithCancel(ctx)
+	defer cancelHealth()
+	var checker *health.Checker
+	if cfg.Health.Enabled {
+		healthServer := grpchealth.NewServer()
+		healthpb.RegisterHealthServer(grpcServer, healthServer)
+
+		var cachePinger health.Pinger
+		if cfg.Health.CheckCache {
+			cachePinger = sqliteCache
+		}
+		if !cfg.Health.CheckCircuit {
+			circuit = nil
+		}
+		checker = health.NewChecker(
+			logger,
+			healthServer,
+			[]string{pb.ThumbnailService_ServiceDesc.ServiceName},
+			cachePinger,
+			circuit,
+			cfg.Health.CheckDraining,
+			cfg.Health.Interval,
+		)
+		go checker.Run(ctxHealth)
+	}
+
+	if cfg.GRPC.Reflection {
+		reflection.Register(grpcServer)
+	}
 
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 
-	lis, err := net.Listen("tcp", *addr)
+	lis, err := net.Listen("tcp", cfg.GRPC.Addr)
 	if err != nil {
-		logger.Error("Failed to listen: %v", err)
+		logger.Error("Failed to listen", slog.Any("err", err))
 		os.Exit(1)
 	}
 
@@ -78,7 +287,99 @@ func main() {
 			os.Exit(1)
 		}
 	}()
-	logger.Info("Server listening", slog.Any("addr", lis.Addr()))
+	logger.Info("Server listening", slog.String("addr", lis.Addr().String()))
+
+	var (
+		httpServer *http.Server
+		gw         *gateway.Handler
+	)
+	if cfg.HTTP.Addr != "" {
+		httpLis, err := net.Listen("tcp", cfg.HTTP.Addr)
+		if err != nil {
+			logger.Error("Failed to listen", slog.Any("err", err))
+			os.Exit(1)
+		}
+
+		gw = gateway.NewHandler(logger, srv, cfg.Cache.TTL)
+		var handler http.Handler = gw
+		if cfg.HTTP.Metrics {
+			mux := http.NewServeMux()
+			mux.Handle("/debug/vars", expvar.Handler())
+			mux.Handle("/", gw)
+			handler = mux
+		}
+		handler = logging.Middleware(logger, handler)
+		if authenticator != nil {
+			handler = authenticator.Middleware(handler)
+		}
+		if cfg.HTTP.GRPCWeb {
+			handler = gateway.NewGRPCWebHandler(grpcServer, cfg.HTTP.GRPCWebAllowedOrigins, handler)
+		}
+
+		if tlsReloader != nil {
+			httpLis = tls.NewListener(httpLis, tlsReloader.ServerConfig())
+		}
+
+		httpServer = &http.Server{
+			Handler:           handler,
+			ReadHeaderTimeout: cfg.Limits.RequestTimeout,
+		}
+		go func() {
+			if err := httpServer.Serve(httpLis); err != nil && err != http.ErrServerClosed {
+				logger.Error("Failed to serve HTTP", slog.Any("err", err))
+				os.Exit(1)
+			}
+		}()
+		logger.Info("HTTP gateway listening", slog.String("addr", httpLis.Addr().String()))
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			next, _, err := loadConfig()
+			if err != nil {
+				logger.Error("Config reload rejected", slog.Any("err", err))
+			} else if err := reloadConfig(logger, current.Load(), next); err != nil {
+				logger.Error("Config reload rejected", slog.Any("err", err))
+			} else {
+				logLevel.Set(parseLevel(next.Log.Level))
+				sqliteCache.SetTTL(next.Cache.TTL)
+				if gw != nil {
+					gw.SetMaxAge(next.Cache.TTL)
+				}
+				srv.SetMaxParallelHTTPRequests(next.Downloader.MaxParallelRequests)
+				srv.SetRequestTimeout(next.Limits.RequestTimeout)
+				srv.SetCropLetterbox(next.Imaging.CropLetterbox)
+				srv.SetMaxParallelTransforms(next.Imaging.MaxParallelTransforms)
+				sizes, _ := imaging.ParseSizes(next.Imaging.Sizes)
+				srv.SetAllowedSizes(sizes)
+				current.Store(next)
+			}
+
+			if tlsReloader != nil {
+				if err := tlsReloader.Reload(); err != nil {
+					logger.Error("Could not reload certificates", slog.Any("err", err))
+				} else {
+					logger.Info("Certificates reloaded")
+				}
+			}
+			if keyStore != nil {
+				if err := keyStore.Reload(); err != nil {
+					logger.Error("Could not reload API keys", slog.Any("err", err))
+				} else {
+					logger.Info("API keys reloaded")
+				}
+			}
+			if adminKeyStore != nil {
+				if err := adminKeyStore.Reload(); err != nil {
+					logger.Error("Could not reload admin keys", slog.Any("err", err))
+				} else {
+					logger.Info("Admin keys reloaded")
+				}
+			}
+		}
+	}()
 
 	select {
 	case <-done:
@@ -86,41 +387,83 @@ func main() {
 	case <-shutdown:
 	}
 
-	ctxShutdown, cancel := context.WithTimeout(ctx, 10*time.Second)
+	signal.Stop(hup)
+	cancelWatch()
+	cancelHealth()
+	if checker != nil {
+		checker.Drain()
+	}
+
+	ctxShutdown, cancel := context.WithTimeout(ctx, current.Load().Limits.ShutdownTimeout)
 	defer cancel()
 
 	stop := make(chan struct{})
 	go func() {
+		if httpServer != nil {
+			if err := httpServer.Shutdown(ctxShutdown); err != nil {
+				logger.Error("Could not stop HTTP gateway", slog.Any("err", err))
+			}
+		}
 		grpcServer.GracefulStop()
 		sqliteCache.Close()
+		if err := shutdownTracing(ctxShutdown); err != nil {
+			logger.Error("Could not flush traces", slog.Any("err", err))
+		}
 		stop <- struct{}{}
 	}()
 
 	select {
 	case <-ctxShutdown.Done():
 		logger.Warn("Timeout on graceful shutdown")
+		if httpServer != nil {
+			httpServer.Close()
+		}
 		grpcServer.Stop()
 	case <-stop:
 		logger.Warn("Graceful shutdown")
 	}
 }
 
-func setupLogger(levelString string) *slog.Logger {
-	var level slog.Level
+// loadConfig resolves config from command line arguments, env and config file.
+// It is called again on SIGHUP.
+func loadConfig() (*config.Config, bool, error) {
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	printConfig := fs.Bool("print-config", false, "print effective config and exit")
+	cfg, err := config.Load(fs, os.Args[1:], os.LookupEnv)
+	return cfg, *printConfig, err
+}
+
+// reloadConfig logs changed settings and rejects reload
+// if any of them requires a restart
+func reloadConfig(logger *slog.Logger, prev *config.Config, next *config.Config) error {
+	changes := prev.Diff(next)
+	if err := config.CheckReload(changes); err != nil {
+		return err
+	}
+
+	if len(changes) == 0 {
+		logger.Info("Config reloaded: no changes")
+	}
+	for _, change := range changes {
+		logger.Info(
+			"Config reloaded",
+			slog.String("key", change.Key),
+			slog.String("old", change.Old),
+			slog.String("new", change.New),
+		)
+	}
+	return nil
+}
+
+func parseLevel(levelString string) slog.Level {
 	switch levelString {
 	case "DEBUG":
-		level = slog.LevelDebug
-	case "INFO":
-		level = slog.LevelInfo
+		return slog.LevelDebug
 	case "WARN":
-		level = slog.LevelWarn
+		return slog.LevelWarn
 	case "ERROR":
-		level = slog.LevelError
+		return slog.LevelError
 	default:
-		panic("unknown level name")
+		return slog.LevelInfo
 	}
-
-	return slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-		Level: level,
-	}))
 }
@@ -0,0 +1,146 @@
+package accesslog
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/pegov/yt-thumbnails-go/internal/logging"
+)
+
+// Cache outcomes
+const (
+	CacheHit   = "hit"
+	CacheMiss  = "miss"
+	CacheStale = "stale"
+	// CacheBypass is a request with no_cache
+	CacheBypass = "bypass"
+)
+
+// Record collects request details that only the handler knows
+type Record struct {
+	mu           sync.Mutex
+	videoID      string
+	cacheOutcome string
+}
+
+type recordContextKey struct{}
+
+func recordFromContext(ctx context.Context) *Record {
+	r, _ := ctx.Value(recordContextKey{}).(*Record)
+	return r
+}
+
+// SetVideoID records video ID for the access log line, no-op without interceptor
+func SetVideoID(ctx context.Context, videoID string) {
+	if r := recordFromContext(ctx); r != nil {
+		r.mu.Lock()
+		r.videoID = videoID
+		r.mu.Unlock()
+	}
+}
+
+// SetCacheOutcome records cache outcome for the access log line, no-op without interceptor
+func SetCacheOutcome(ctx context.Context, outcome string) {
+	if r := recordFromContext(ctx); r != nil {
+		r.mu.Lock()
+		r.cacheOutcome = outcome
+		r.mu.Unlock()
+	}
+}
+
+// Detach returns ctx without the record, so nested handler calls
+// don't overwrite details of the outer RPC
+func Detach(ctx context.Context) context.Context {
+	return context.WithValue(ctx, recordContextKey{}, (*Record)(nil))
+}
+
+// Logger writes one line per RPC. Errors are always logged,
+// successes are skipped if errorsOnly is set, otherwise sampled.
+type Logger struct {
+	logger        *slog.Logger
+	errorsOnly    bool
+	samplePercent float64
+}
+
+func New(logger *slog.Logger, errorsOnly bool, samplePercent float64) *Logger {
+	return &Logger{
+		logger:        logger,
+		errorsOnly:    errorsOnly,
+		samplePercent: samplePercent,
+	}
+}
+
+func (l *Logger) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		start := time.Now()
+		r := &Record{}
+		res, err := handler(context.WithValue(ctx, recordContextKey{}, r), req)
+
+		code := status.Code(err)
+		if !l.sampled(code) {
+			return res, err
+		}
+
+		var size int
+		if m, ok := res.(proto.Message); ok && err == nil {
+			size = proto.Size(m)
+		}
+
+		r.mu.Lock()
+		attrs := []slog.Attr{
+			slog.String("request_id", logging.RequestIDFromContext(ctx)),
+			slog.String("method", info.FullMethod),
+			slog.String("code", code.String()),
+			slog.Duration("latency", time.Since(start)),
+			slog.Int("bytes", size),
+			slog.String("cache", r.cacheOutcome),
+			slog.String("video_id", r.videoID),
+		}
+		r.mu.Unlock()
+
+		level := slog.LevelInfo
+		if code != codes.OK {
+			level = slog.LevelWarn
+		}
+		l.logger.LogAttrs(ctx, level, "access", attrs...)
+
+		return res, err
+	}
+}
+
+func (l *Logger) sampled(code codes.Code) bool {
+	if code != codes.OK {
+		return true
+	}
+	if l.errorsOnly {
+		return false
+	}
+	return l.samplePercent >= 100 || rand.Float64()*100 < l.samplePercent
+}
+
+// NewRotatingFile returns file writer rotated by size. Zero maxBackups or
+// maxAgeDays keeps all old files.
+func NewRotatingFile(path string, maxSizeMB int, maxBackups int, maxAgeDays int) io.WriteCloser {
+	return &lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    maxSizeMB,
+		MaxBackups: maxBackups,
+		MaxAge:     maxAgeDays,
+		Compress:   true,
+	}
+}
@@ -0,0 +1,97 @@
+package accesslog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pegov/yt-thumbnails-go/api/thumbnail_v1"
+)
+
+var info = &grpc.UnaryServerInfo{FullMethod: "/ThumbnailService/Get"}
+
+func ok(ctx context.Context, req any) (any, error) {
+	SetVideoID(ctx, "dQw4w9WgXcQ")
+	SetCacheOutcome(ctx, CacheHit)
+	return &pb.GetResponse{VideoId: "dQw4w9WgXcQ", Data: []byte("image")}, nil
+}
+
+func notFound(ctx context.Context, req any) (any, error) {
+	SetVideoID(ctx, "dQw4wXXXXXX")
+	SetCacheOutcome(ctx, CacheMiss)
+	return nil, status.Error(codes.NotFound, "not found")
+}
+
+func lines(buf *bytes.Buffer) []map[string]any {
+	var res []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var m map[string]any
+		json.Unmarshal([]byte(line), &m)
+		res = append(res, m)
+	}
+	return res
+}
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(slog.New(slog.NewJSONHandler(&buf, nil)), false, 100)
+
+	l.UnaryInterceptor()(context.Background(), nil, info, ok)
+	l.UnaryInterceptor()(context.Background(), nil, info, notFound)
+
+	logged := lines(&buf)
+	assert.Len(t, logged, 2)
+	assert.Equal(t, "/ThumbnailService/Get", logged[0]["method"])
+	assert.Equal(t, "OK", logged[0]["code"])
+	assert.Equal(t, "hit", logged[0]["cache"])
+	assert.Equal(t, "dQw4w9WgXcQ", logged[0]["video_id"])
+	assert.Greater(t, logged[0]["bytes"], float64(len("image")))
+	assert.Contains(t, logged[0], "latency")
+
+	assert.Equal(t, "NotFound", logged[1]["code"])
+	assert.Equal(t, "miss", logged[1]["cache"])
+	assert.Equal(t, float64(0), logged[1]["bytes"])
+}
+
+func TestAccessLogErrorsOnly(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(slog.New(slog.NewJSONHandler(&buf, nil)), true, 100)
+
+	l.UnaryInterceptor()(context.Background(), nil, info, ok)
+	l.UnaryInterceptor()(context.Background(), nil, info, notFound)
+
+	logged := lines(&buf)
+	assert.Len(t, logged, 1)
+	assert.Equal(t, "NotFound", logged[0]["code"])
+}
+
+func TestAccessLogSampling(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(slog.New(slog.NewJSONHandler(&buf, nil)), false, 0)
+
+	for i := 0; i < 100; i++ {
+		l.UnaryInterceptor()(context.Background(), nil, info, ok)
+	}
+	l.UnaryInterceptor()(context.Background(), nil, info, notFound)
+
+	// Errors are never sampled out
+	logged := lines(&buf)
+	assert.Len(t, logged, 1)
+	assert.Equal(t, "NotFound", logged[0]["code"])
+}
+
+func TestSetWithoutInterceptor(t *testing.T) {
+	SetVideoID(context.Background(), "dQw4w9WgXcQ")
+	SetCacheOutcome(context.Background(), CacheHit)
+}
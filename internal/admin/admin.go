@@ -0,0 +1,150 @@
+package admin
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pegov/yt-thumbnails-go/api/thumbnail_v1"
+	"github.com/pegov/yt-thumbnails-go/internal/auth"
+	"github.com/pegov/yt-thumbnails-go/internal/cache"
+	"github.com/pegov/yt-thumbnails-go/internal/logging"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+var errInternal = status.Error(codes.Internal, "internal")
+
+type Cache interface {
+	Invalidate(ctx context.Context, videoIDs []string) (int64, error)
+	Purge(ctx context.Context) (int64, error)
+	Stats(ctx context.Context) (cache.Stats, error)
+	List(ctx context.Context, opts cache.ListOptions) ([]cache.Entry, error)
+}
+
+type server struct {
+	pb.UnimplementedAdminServiceServer
+
+	logger *slog.Logger
+	cache  Cache
+}
+
+func NewServer(logger *slog.Logger, cache Cache) *server {
+	return &server{logger: logger, cache: cache}
+}
+
+func (s *server) Invalidate(ctx context.Context, req *pb.InvalidateRequest) (*pb.InvalidateResponse, error) {
+	if len(req.VideoIds) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "video_ids: required")
+	}
+
+	logger := s.requestLogger(ctx)
+	deleted, err := s.cache.Invalidate(ctx, req.VideoIds)
+	if err != nil {
+		logger.Error("Invalidate: internal error", slog.Any("err", err))
+		return nil, errInternal
+	}
+	logger.Info(
+		"Invalidate",
+		slog.Any("video_ids", req.VideoIds),
+		slog.Int64("deleted", deleted),
+	)
+	return &pb.InvalidateResponse{Deleted: deleted}, nil
+}
+
+func (s *server) Purge(ctx context.Context, req *pb.PurgeRequest) (*pb.PurgeResponse, error) {
+	logger := s.requestLogger(ctx)
+	deleted, err := s.cache.Purge(ctx)
+	if err != nil {
+		logger.Error("Purge: internal error", slog.Any("err", err))
+		return nil, errInternal
+	}
+	logger.Info("Purge", slog.Int64("deleted", deleted))
+	return &pb.PurgeResponse{Deleted: deleted}, nil
+}
+
+func (s *server) Stats(ctx context.Context, req *pb.StatsRequest) (*pb.StatsResponse, error) {
+	stats, err := s.cache.Stats(ctx)
+	if err != nil {
+		s.requestLogger(ctx).Error("Stats: internal error", slog.Any("err", err))
+		return nil, errInternal
+	}
+
+	var hitRatio float64
+	if total := stats.Hits + stats.Misses; total > 0 {
+		hitRatio = float64(stats.Hits) / float64(total)
+	}
+	return &pb.StatsResponse{
+		Entries:     stats.Entries,
+		Bytes:       stats.Bytes,
+		Hits:        stats.Hits,
+		Misses:      stats.Misses,
+		HitRatio:    hitRatio,
+		OldestEntry: stats.Oldest,
+	}, nil
+}
+
+func (s *server) List(ctx context.Context, req *pb.ListRequest) (*pb.ListResponse, error) {
+	pageSize := int(req.PageSize)
+	switch {
+	case pageSize < 0:
+		return nil, status.Error(codes.InvalidArgument, "page_size: must not be negative")
+	case pageSize == 0:
+		pageSize = defaultPageSize
+	case pageSize > maxPageSize:
+		pageSize = maxPageSize
+	}
+
+	var afterID int64
+	if req.PageToken != "" {
+		id, err := strconv.ParseInt(req.PageToken, 10, 64)
+		if err != nil || id < 0 {
+			return nil, status.Error(codes.InvalidArgument, "page_token: invalid token")
+		}
+		afterID = id
+	}
+
+	// One extra entry tells whether there is a next page
+	entries, err := s.cache.List(ctx, cache.ListOptions{
+		Limit:         pageSize + 1,
+		AfterID:       afterID,
+		VideoIDPrefix: req.VideoIdPrefix,
+		ExpiredOnly:   req.ExpiredOnly,
+		FetchedAfter:  req.FetchedAfter,
+		FetchedBefore: req.FetchedBefore,
+	})
+	if err != nil {
+		s.requestLogger(ctx).Error("List: internal error", slog.Any("err", err))
+		return nil, errInternal
+	}
+
+	res := &pb.ListResponse{}
+	if len(entries) > pageSize {
+		entries = entries[:pageSize]
+		res.NextPageToken = strconv.FormatInt(entries[len(entries)-1].ID, 10)
+	}
+	res.Entries = make([]*pb.CacheEntry, len(entries))
+	for i, e := range entries {
+		res.Entries[i] = &pb.CacheEntry{
+			VideoId:   e.VideoID,
+			ByteSize:  e.Size,
+			FetchedAt: e.TS,
+			Expired:   e.Expired,
+		}
+	}
+	return res, nil
+}
+
+func (s *server) requestLogger(ctx context.Context) *slog.Logger {
+	logger := logging.FromContext(ctx, s.logger)
+	if keyID, ok := auth.KeyIDFromContext(ctx); ok {
+		logger = logger.With(slog.String("key_id", keyID))
+	}
+	return logger
+}
@@ -0,0 +1,79 @@
+package admin
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pegov/yt-thumbnails-go/api/thumbnail_v1"
+	"github.com/pegov/yt-thumbnails-go/internal/cache"
+	"github.com/pegov/yt-thumbnails-go/internal/cache/sqlite"
+)
+
+var ctx = context.Background()
+
+func newTestServer(t *testing.T) (*server, *sqlite.SQLiteCache) {
+	c, err := sqlite.New(ctx, ":memory:", time.Hour)
+	assert.Nil(t, err)
+	t.Cleanup(c.Close)
+	return NewServer(slog.Default(), c), c
+}
+
+func TestInvalidate(t *testing.T) {
+	s, c := newTestServer(t)
+	c.Set(ctx, "a", []byte("a"), cache.Metadata{FetchedAt: time.Now().Unix()})
+	c.Set(ctx, "a/hqdefault", []byte("a"), cache.Metadata{FetchedAt: time.Now().Unix()})
+
+	res, err := s.Invalidate(ctx, &pb.InvalidateRequest{VideoIds: []string{"a"}})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), res.Deleted)
+
+	_, err = s.Invalidate(ctx, &pb.InvalidateRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestStatsAndPurge(t *testing.T) {
+	s, c := newTestServer(t)
+	c.Set(ctx, "old", []byte("old"), cache.Metadata{FetchedAt: time.Now().Add(-2 * time.Hour).Unix()})
+	c.Set(ctx, "new", []byte("new"), cache.Metadata{FetchedAt: time.Now().Unix()})
+	c.Get(ctx, "new", 0)
+	c.Get(ctx, "missing", 0)
+	c.Get(ctx, "new", 0)
+	c.Get(ctx, "new", 0)
+
+	stats, err := s.Stats(ctx, &pb.StatsRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), stats.Entries)
+	assert.Equal(t, int64(6), stats.Bytes)
+	assert.Equal(t, 0.75, stats.HitRatio)
+
+	res, err := s.Purge(ctx, &pb.PurgeRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), res.Deleted)
+}
+
+func TestListPagination(t *testing.T) {
+	s, c := newTestServer(t)
+	for _, id := range []string{"a", "b", "c"} {
+		c.Set(ctx, id, []byte(id), cache.Metadata{FetchedAt: time.Now().Unix()})
+	}
+
+	res, err := s.List(ctx, &pb.ListRequest{PageSize: 2})
+	assert.Nil(t, err)
+	assert.Len(t, res.Entries, 2)
+	assert.NotEmpty(t, res.NextPageToken)
+
+	res, err = s.List(ctx, &pb.ListRequest{PageSize: 2, PageToken: res.NextPageToken})
+	assert.Nil(t, err)
+	assert.Len(t, res.Entries, 1)
+	assert.Equal(t, "c", res.Entries[0].VideoId)
+	assert.Empty(t, res.NextPageToken)
+
+	_, err = s.List(ctx, &pb.ListRequest{PageToken: "x"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	apiKeyHeader        = "x-api-key"
+	authorizationHeader = "authorization"
+	bearerPrefix        = "bearer "
+)
+
+type keyIDContextKey struct{}
+
+// KeyIDFromContext returns ID of the key that authenticated the request
+func KeyIDFromContext(ctx context.Context) (string, bool) {
+	keyID, ok := ctx.Value(keyIDContextKey{}).(string)
+	return keyID, ok
+}
+
+// Authenticator checks API key or bearer token from gRPC metadata
+// (or HTTP headers) against the Store.
+type Authenticator struct {
+	store *Store
+	// Full method name prefixes available without a key
+	// (e.g. "/grpc.health.v1.Health/")
+	public []string
+	// If set, only methods under these prefixes are checked
+	scope []string
+}
+
+func NewAuthenticator(store *Store, public []string) *Authenticator {
+	return &Authenticator{store: store, public: public}
+}
+
+// NewScopedAuthenticator checks keys only for methods under given prefixes,
+// e.g. a separate admin key store for "/AdminService/".
+func NewScopedAuthenticator(store *Store, scope []string) *Authenticator {
+	return &Authenticator{store: store, scope: scope}
+}
+
+func (a *Authenticator) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		if a.isPublic(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		ctx, err := a.authenticate(ctx, tokenFromMetadata(ctx))
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (a *Authenticator) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		if a.isPublic(info.FullMethod) {
+			return handler(srv, ss)
+		}
+
+		ctx, err := a.authenticate(ss.Context(), tokenFromMetadata(ss.Context()))
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ss, ctx})
+	}
+}
+
+// Middleware authenticates plain HTTP requests by the same headers
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get(apiKeyHeader)
+		if token == "" {
+			token = bearerToken(r.Header.Get(authorizationHeader))
+		}
+
+		ctx, err := a.authenticate(r.Context(), token)
+		if err != nil {
+			switch status.Code(err) {
+			case codes.ResourceExhausted:
+				http.Error(w, status.Convert(err).Message(), http.StatusTooManyRequests)
+			default:
+				http.Error(w, status.Convert(err).Message(), http.StatusUnauthorized)
+			}
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (a *Authenticator) authenticate(ctx context.Context, token string) (context.Context, error) {
+	if token == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing api key")
+	}
+
+	keyID, err := a.store.Allow(token, time.Now())
+	switch {
+	case errors.Is(err, ErrUnknownKey):
+		return nil, status.Error(codes.Unauthenticated, "invalid api key")
+	case errors.Is(err, ErrRateLimited):
+		return nil, status.Error(codes.ResourceExhausted, "rate limit exceeded")
+	case errors.Is(err, ErrQuotaExceeded):
+		return nil, status.Error(codes.ResourceExhausted, "daily quota exceeded")
+	}
+
+	return context.WithValue(ctx, keyIDContextKey{}, keyID), nil
+}
+
+func (a *Authenticator) isPublic(method string) bool {
+	if len(a.scope) > 0 && !hasPrefix(method, a.scope) {
+		return true
+	}
+	return hasPrefix(method, a.public)
+}
+
+func hasPrefix(method string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func tokenFromMetadata(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(apiKeyHeader); len(values) > 0 {
+		return values[0]
+	}
+	if values := md.Get(authorizationHeader); len(values) > 0 {
+		return bearerToken(values[0])
+	}
+	return ""
+}
+
+func bearerToken(header string) string {
+	if len(header) > len(bearerPrefix) && strings.EqualFold(header[:len(bearerPrefix)], bearerPrefix) {
+		return header[len(bearerPrefix):]
+	}
+	return ""
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const keys = `{"keys": [
+	{"id": "frontend", "key": "secret-1", "rate_limit": 1, "burst": 2},
+	{"id": "batch", "key": "secret-2", "daily_quota": 2}
+]}`
+
+func newStore(t *testing.T, content string) (*Store, string) {
+	p := filepath.Join(t.TempDir(), "keys.json")
+	assert.Nil(t, os.WriteFile(p, []byte(content), 0600))
+	s, err := NewStore(slog.Default(), p)
+	assert.Nil(t, err)
+	return s, p
+}
+
+func call(a *Authenticator, method string, md metadata.MD) (string, error) {
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+	var keyID string
+	_, err := a.UnaryInterceptor()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method},
+		func(ctx context.Context, req any) (any, error) {
+			keyID, _ = KeyIDFromContext(ctx)
+			return nil, nil
+		})
+	return keyID, err
+}
+
+func TestStoreRateLimit(t *testing.T) {
+	s, _ := newStore(t, keys)
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		id, err := s.Allow("secret-1", now)
+		assert.Nil(t, err)
+		assert.Equal(t, "frontend", id)
+	}
+	_, err := s.Allow("secret-1", now)
+	assert.ErrorIs(t, err, ErrRateLimited)
+
+	_, err = s.Allow("secret-1", now.Add(time.Second))
+	assert.Nil(t, err)
+}
+
+func TestStoreDailyQuota(t *testing.T) {
+	s, _ := newStore(t, keys)
+	day := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		_, err := s.Allow("secret-2", day)
+		assert.Nil(t, err)
+	}
+	_, err := s.Allow("secret-2", day)
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+
+	_, err = s.Allow("secret-2", day.Add(24*time.Hour))
+	assert.Nil(t, err)
+}
+
+func TestStoreReload(t *testing.T) {
+	s, p := newStore(t, keys)
+
+	_, err := s.Allow("secret-3", time.Now())
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	os.WriteFile(p, []byte(`{"keys": [{"id": "new", "key": "secret-3"}]}`), 0600)
+	assert.Nil(t, s.Reload())
+
+	id, err := s.Allow("secret-3", time.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, "new", id)
+	_, err = s.Allow("secret-1", time.Now())
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	// Broken file keeps previous keys
+	os.WriteFile(p, []byte(`{"keys": [`), 0600)
+	assert.ErrorIs(t, s.Reload(), ErrInvalidKeyFile)
+	_, err = s.Allow("secret-3", time.Now())
+	assert.Nil(t, err)
+}
+
+func TestStoreWatch(t *testing.T) {
+	s, p := newStore(t, keys)
+	var buf bytes.Buffer
+	s.logger = slog.New(slog.NewTextHandler(&buf, nil))
+
+	os.WriteFile(p, []byte(`{"keys": [`), 0600)
+	os.Chtimes(p, time.Now(), time.Now().Add(time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Watch(ctx, time.Millisecond)
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-done
+
+	// Reported once and previous keys stay in use
+	assert.Equal(t, 1, strings.Count(buf.String(), "Could not reload API keys"))
+	_, err := s.Allow("secret-1", time.Now())
+	assert.Nil(t, err)
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	s, _ := newStore(t, keys)
+	a := NewAuthenticator(s, []string{"/grpc.health.v1.Health/"})
+
+	keyID, err := call(a, "/ThumbnailService/Get", metadata.Pairs("x-api-key", "secret-1"))
+	assert.Nil(t, err)
+	assert.Equal(t, "frontend", keyID)
+
+	keyID, err = call(a, "/ThumbnailService/Get", metadata.Pairs("authorization", "Bearer secret-2"))
+	assert.Nil(t, err)
+	assert.Equal(t, "batch", keyID)
+
+	_, err = call(a, "/ThumbnailService/Get", metadata.Pairs("x-api-key", "wrong"))
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = call(a, "/ThumbnailService/Get", metadata.MD{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// Burst of 2 requests per key
+	call(a, "/ThumbnailService/Get", metadata.Pairs("x-api-key", "secret-1"))
+	_, err = call(a, "/ThumbnailService/Get", metadata.Pairs("x-api-key", "secret-1"))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	_, err = call(a, "/grpc.health.v1.Health/Check", metadata.MD{})
+	assert.Nil(t, err)
+}
+
+func TestScopedAuthenticator(t *testing.T) {
+	s, _ := newStore(t, keys)
+	a := NewScopedAuthenticator(s, []string{"/AdminService/"})
+
+	_, err := call(a, "/ThumbnailService/Get", metadata.MD{})
+	assert.Nil(t, err)
+
+	_, err = call(a, "/AdminService/Purge", metadata.MD{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	keyID, err := call(a, "/AdminService/Purge", metadata.Pairs("x-api-key", "secret-2"))
+	assert.Nil(t, err)
+	assert.Equal(t, "batch", keyID)
+}
+
+func TestMiddleware(t *testing.T) {
+	s, _ := newStore(t, keys)
+	a := NewAuthenticator(s, nil)
+	h := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keyID, _ := KeyIDFromContext(r.Context())
+		w.Write([]byte(keyID))
+	}))
+
+	req := httptest.NewRequest("GET", "/vi/dQw4w9WgXcQ/hqdefault.jpg", nil)
+	req.Header.Set("X-Api-Key", "secret-2")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "batch", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/vi/dQw4w9WgXcQ/hqdefault.jpg", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
@@ -0,0 +1,180 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+var (
+	ErrUnknownKey     = errors.New("unknown key")
+	ErrRateLimited    = errors.New("rate limit exceeded")
+	ErrQuotaExceeded  = errors.New("daily quota exceeded")
+	ErrDuplicateKey   = errors.New("duplicate key")
+	ErrInvalidKeyFile = errors.New("invalid key file")
+)
+
+// Key is one entry of the key store file:
+//
+//	{"keys": [{"id": "frontend", "key": "...", "rate_limit": 10, "burst": 20, "daily_quota": 10000}]}
+//
+// Zero rate limit or daily quota means unlimited.
+type Key struct {
+	ID         string  `json:"id"`
+	Key        string  `json:"key"`
+	RateLimit  float64 `json:"rate_limit"`
+	Burst      int     `json:"burst"`
+	DailyQuota int64   `json:"daily_quota"`
+}
+
+type keyFile struct {
+	Keys []Key `json:"keys"`
+}
+
+type keyState struct {
+	Key
+	limiter *rate.Limiter
+	day     string
+	used    int64
+}
+
+// Store holds API keys loaded from a file together with their
+// rate limiters and daily usage. Usage survives reloads.
+type Store struct {
+	logger   *slog.Logger
+	filepath string
+
+	mu      sync.Mutex
+	keys    map[string]*keyState
+	modTime time.Time
+}
+
+func NewStore(logger *slog.Logger, filepath string) (*Store, error) {
+	s := &Store{
+		logger:   logger,
+		filepath: filepath,
+		keys:     make(map[string]*keyState),
+	}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload reads key file again. On error previous keys stay in use.
+func (s *Store) Reload() error {
+	info, err := os.Stat(s.filepath)
+	if err != nil {
+		return err
+	}
+	b, err := os.ReadFile(s.filepath)
+	if err != nil {
+		return err
+	}
+
+	var f keyFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return errors.Join(ErrInvalidKeyFile, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make(map[string]*keyState, len(f.Keys))
+	for _, k := range f.Keys {
+		if k.ID == "" || k.Key == "" {
+			return ErrInvalidKeyFile
+		}
+		if _, ok := keys[k.Key]; ok {
+			return ErrDuplicateKey
+		}
+
+		limit := rate.Inf
+		if k.RateLimit > 0 {
+			limit = rate.Limit(k.RateLimit)
+		}
+		burst := k.Burst
+		if burst <= 0 {
+			burst = max(1, int(k.RateLimit))
+		}
+
+		state, ok := s.keys[k.Key]
+		if ok && state.ID == k.ID {
+			state.Key = k
+			state.limiter.SetLimit(limit)
+			state.limiter.SetBurst(burst)
+		} else {
+			state = &keyState{Key: k, limiter: rate.NewLimiter(limit, burst)}
+		}
+		keys[k.Key] = state
+	}
+
+	s.keys = keys
+	s.modTime = info.ModTime()
+	return nil
+}
+
+// Watch reloads key file every time it is modified
+func (s *Store) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(s.filepath)
+			if err != nil {
+				continue
+			}
+			s.mu.Lock()
+			changed := !info.ModTime().Equal(s.modTime)
+			s.mu.Unlock()
+			if !changed {
+				continue
+			}
+			if err := s.Reload(); err != nil {
+				// Broken version is reported once, not on every tick
+				s.mu.Lock()
+				s.modTime = info.ModTime()
+				s.mu.Unlock()
+				s.logger.Error("Could not reload API keys", slog.Any("err", err))
+				continue
+			}
+			s.logger.Info("API keys reloaded")
+		}
+	}
+}
+
+// Allow checks key and charges one request against its rate limit
+// and daily quota. It returns ID of the key.
+func (s *Store) Allow(key string, now time.Time) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.keys[key]
+	if !ok {
+		return "", ErrUnknownKey
+	}
+
+	if day := now.UTC().Format(time.DateOnly); state.day != day {
+		state.day = day
+		state.used = 0
+	}
+	if state.DailyQuota > 0 && state.used >= state.DailyQuota {
+		return state.ID, ErrQuotaExceeded
+	}
+	if !state.limiter.AllowN(now, 1) {
+		return state.ID, ErrRateLimited
+	}
+	state.used++
+
+	return state.ID, nil
+}
@@ -1,8 +1,84 @@
 package cache
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrNotFound = errors.New("not found")
+	// ErrExpired is ErrNotFound for an entry that exists but is older than TTL
+	ErrExpired  = fmt.Errorf("expired: %w", ErrNotFound)
 	ErrInternal = errors.New("internal")
 )
+
+// Stats describes cache contents. Hits and misses are counted since start.
+type Stats struct {
+	Entries int64
+	Bytes   int64
+	Hits    int64
+	Misses  int64
+	// Oldest is unix time of the oldest entry, 0 for empty cache
+	Oldest int64
+}
+
+// Entry is a cached thumbnail without its data
+type Entry struct {
+	ID      int64
+	VideoID string
+	Size    int64
+	TS      int64
+	Expired bool
+}
+
+// ListOptions filters and paginates List. Zero values disable filters.
+type ListOptions struct {
+	Limit int
+	// AfterID is the ID of the last entry of the previous page
+	AfterID       int64
+	VideoIDPrefix string
+	ExpiredOnly   bool
+	FetchedAfter  int64
+	FetchedBefore int64
+}
+
+// Metadata is computed once at download time and stored next to the image
+type Metadata struct {
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	// SHA256 of data, hex encoded
+	SHA256 string `json:"sha256"`
+	// FetchedAt is unix time of download
+	FetchedAt int64  `json:"fetched_at"`
+	SourceURL string `json:"source_url"`
+	Variant   string `json:"variant"`
+	// Crop is area of the source image kept by letterbox cropping
+	Crop *Rect `json:"crop,omitempty"`
+	// BlurHash and DominantColor (#rrggbb) are placeholders shown while
+	// the image is loading
+	BlurHash      string `json:"blurhash,omitempty"`
+	DominantColor string `json:"dominant_color,omitempty"`
+	// PHash is hex encoded 64-bit perceptual hash, set only for original
+	// images. Entries with it are searchable with FindSimilar.
+	PHash string `json:"phash,omitempty"`
+}
+
+// MaxDistance is the largest Hamming distance FindSimilar searches
+const MaxDistance = 11
+
+// Similar is a video with a thumbnail close to the searched one
+type Similar struct {
+	VideoID string
+	// Distance is Hamming distance of perceptual hashes
+	Distance int
+}
+
+// Rect is an image area in pixels
+type Rect struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
@@ -3,7 +3,14 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -17,34 +24,72 @@ const (
 		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
 		video_id TEXT,
 		data BLOB,
-		ts INTEGER
+		ts INTEGER,
+		meta TEXT
 	);
 	CREATE INDEX IF NOT EXISTS thumbnail_video_id_idx ON thumbnail(video_id);
 	`
+	// Perceptual hash is split into 16-bit chunks, each one indexed
+	sqlInitPHash = `
+	CREATE INDEX IF NOT EXISTS thumbnail_phash0_idx ON thumbnail(phash0);
+	CREATE INDEX IF NOT EXISTS thumbnail_phash1_idx ON thumbnail(phash1);
+	CREATE INDEX IF NOT EXISTS thumbnail_phash2_idx ON thumbnail(phash2);
+	CREATE INDEX IF NOT EXISTS thumbnail_phash3_idx ON thumbnail(phash3);
+	`
 	sqlInsert = `
-	INSERT INTO thumbnail (video_id, data, ts) VALUES (
-		?, ?, ?
+	INSERT INTO thumbnail (video_id, data, ts, meta, phash, phash0, phash1, phash2, phash3) VALUES (
+		?, ?, ?, ?, ?, ?, ?, ?, ?
 	);
 	`
 	sqlSelect = `
-	SELECT data, ts FROM thumbnail WHERE video_id = ?;
+	SELECT data, ts, meta FROM thumbnail WHERE video_id = ? ORDER BY id DESC LIMIT 1;
+	`
+	sqlDelete = `
+	DELETE FROM thumbnail WHERE video_id = ?;
+	`
+	// Deletes the video and all its variants (video_id/variant)
+	sqlDeleteVideo = `
+	DELETE FROM thumbnail WHERE video_id = ?1 OR substr(video_id, 1, length(?1) + 1) = ?1 || '/';
+	`
+	sqlDeleteExpired = `
+	DELETE FROM thumbnail WHERE ts < ?;
+	`
+	sqlStats = `
+	SELECT count(*), coalesce(sum(length(data)), 0), coalesce(min(ts), 0) FROM thumbnail;
+	`
+	sqlList = `
+	SELECT id, video_id, length(data), ts FROM thumbnail
+	WHERE id > ?1
+		AND (?2 = '' OR substr(video_id, 1, length(?2)) = ?2)
+		AND (?3 = 0 OR ts < ?4)
+		AND (?5 = 0 OR ts > ?5)
+		AND (?6 = 0 OR ts < ?6)
+	ORDER BY id
+	LIMIT ?7;
 	`
 )
 
-const exp = 60 * 60 * 24
-
 type SQLiteCache struct {
+	// ttl is how long a cached thumbnail stays valid (time.Duration)
+	ttl        atomic.Int64
 	db         *sql.DB
 	insertStmt *sql.Stmt
 	selectStmt *sql.Stmt
+	hits       atomic.Int64
+	misses     atomic.Int64
 }
 
-func New(ctx context.Context, filepath string) (*SQLiteCache, error) {
+func New(ctx context.Context, filepath string, ttl time.Duration) (*SQLiteCache, error) {
 	db, err := sql.Open("sqlite3", filepath)
 	if err != nil {
 		return nil, err
 	}
 
+	if filepath == ":memory:" {
+		// Every connection would get its own empty in-memory database
+		db.SetMaxOpenConns(1)
+	}
+
 	if err := db.PingContext(ctx); err != nil {
 		return nil, err
 	}
@@ -52,6 +97,19 @@ func New(ctx context.Context, filepath string) (*SQLiteCache, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Databases created before metadata and perceptual hashes were stored
+	for _, name := range []string{"meta", "phash", "phash0", "phash1", "phash2", "phash3"} {
+		decl := "INTEGER"
+		if name == "meta" {
+			decl = "TEXT"
+		}
+		if err := addColumn(ctx, db, name, decl); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := db.ExecContext(ctx, sqlInitPHash); err != nil {
+		return nil, err
+	}
 
 	insertStmt, err := db.PrepareContext(ctx, sqlInsert)
 	if err != nil {
@@ -62,47 +120,95 @@ func New(ctx context.Context, filepath string) (*SQLiteCache, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &SQLiteCache{db, insertStmt, selectStmt}, nil
+	c := &SQLiteCache{db: db, insertStmt: insertStmt, selectStmt: selectStmt}
+	c.ttl.Store(int64(ttl))
+	return c, nil
 }
 
-// Get grabs thumbnail from cache
-func (c *SQLiteCache) Get(ctx context.Context, videoID string) ([]byte, error) {
+// Get grabs thumbnail and its metadata from cache. Entries older than
+// maxAge (or TTL if it is shorter or maxAge is 0) return ErrExpired.
+func (c *SQLiteCache) Get(ctx context.Context, videoID string, maxAge time.Duration) ([]byte, cache.Metadata, error) {
 	row := c.selectStmt.QueryRowContext(ctx, videoID)
 	var (
-		b  []byte
-		ts int64
+		b        []byte
+		ts       int64
+		metaJSON sql.NullString
+		meta     cache.Metadata
 	)
-	err := row.Scan(&b, &ts)
+	err := row.Scan(&b, &ts, &metaJSON)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return b, cache.ErrNotFound
+			c.misses.Add(1)
+			return b, meta, cache.ErrNotFound
 		} else {
-			return b, cache.ErrInternal
+			return b, meta, internalError(ctx)
+		}
+	}
+
+	// Rows written before metadata was stored only have size and time
+	meta.Size = int64(len(b))
+	if metaJSON.Valid {
+		if err := json.Unmarshal([]byte(metaJSON.String), &meta); err != nil {
+			return b, meta, internalError(ctx)
 		}
 	}
+	meta.FetchedAt = ts
 
+	ttl := c.TTL()
+	if maxAge > 0 && maxAge < ttl {
+		ttl = maxAge
+	}
 	now := time.Now().Unix()
 	delta := now - ts
-	// Cache is valid only for 24 hours
-	if delta > exp {
-		return b, cache.ErrNotFound
+	if delta > int64(ttl/time.Second) {
+		c.misses.Add(1)
+		return b, meta, cache.ErrExpired
 	}
 
-	return b, nil
+	c.hits.Add(1)
+	return b, meta, nil
 }
 
-// Set saves thumbnails to cache
+// Set saves thumbnail with its metadata to cache, replacing the previous entry.
+// meta.FetchedAt is used as entry time.
 func (c *SQLiteCache) Set(
 	ctx context.Context,
 	videoID string,
 	data []byte,
-	ts int64,
+	meta cache.Metadata,
 ) error {
-	_, err := c.insertStmt.ExecContext(ctx, videoID, data, ts)
+	metaJSON, err := json.Marshal(meta)
+	if err != nil {
+		return internalError(ctx)
+	}
+	// NULL for images without hash
+	phash := make([]any, 5)
+	if meta.PHash != "" {
+		hash, err := strconv.ParseUint(meta.PHash, 16, 64)
+		if err != nil {
+			return internalError(ctx)
+		}
+		phash[0] = int64(hash)
+		for i, chunk := range chunks(hash) {
+			phash[i+1] = chunk
+		}
+	}
 
+	tx, err := c.db.BeginTx(ctx, nil)
 	if err != nil {
-		return cache.ErrInternal
+		return internalError(ctx)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, sqlDelete, videoID); err != nil {
+		return internalError(ctx)
+	}
+	if _, err := tx.StmtContext(ctx, c.insertStmt).ExecContext(ctx, append([]any{videoID, data, meta.FetchedAt, string(metaJSON)}, phash...)...); err != nil {
+		return internalError(ctx)
+	}
+	if err := tx.Commit(); err != nil {
+		return internalError(ctx)
 	}
 
 	// TODO: Optionally clear expired items on every ~10th set to save space.
@@ -110,6 +216,247 @@ func (c *SQLiteCache) Set(
 	return nil
 }
 
+func (c *SQLiteCache) TTL() time.Duration {
+	return time.Duration(c.ttl.Load())
+}
+
+// SetTTL changes TTL at runtime, already cached entries are checked against the new one
+func (c *SQLiteCache) SetTTL(ttl time.Duration) {
+	c.ttl.Store(int64(ttl))
+}
+
+// Invalidate deletes all cached variants of given videos
+func (c *SQLiteCache) Invalidate(ctx context.Context, videoIDs []string) (int64, error) {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, internalError(ctx)
+	}
+	defer tx.Rollback()
+
+	var deleted int64
+	for _, videoID := range videoIDs {
+		res, err := tx.ExecContext(ctx, sqlDeleteVideo, videoID)
+		if err != nil {
+			return 0, internalError(ctx)
+		}
+		n, _ := res.RowsAffected()
+		deleted += n
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, internalError(ctx)
+	}
+	return deleted, nil
+}
+
+// Purge deletes entries older than TTL
+func (c *SQLiteCache) Purge(ctx context.Context) (int64, error) {
+	res, err := c.db.ExecContext(ctx, sqlDeleteExpired, c.expiredBefore())
+	if err != nil {
+		return 0, internalError(ctx)
+	}
+	deleted, _ := res.RowsAffected()
+	return deleted, nil
+}
+
+func (c *SQLiteCache) Stats(ctx context.Context) (cache.Stats, error) {
+	stats := cache.Stats{Hits: c.hits.Load(), Misses: c.misses.Load()}
+	row := c.db.QueryRowContext(ctx, sqlStats)
+	if err := row.Scan(&stats.Entries, &stats.Bytes, &stats.Oldest); err != nil {
+		return stats, internalError(ctx)
+	}
+	return stats, nil
+}
+
+// List returns entries ordered by ID, use the last ID as AfterID for the next page
+func (c *SQLiteCache) List(ctx context.Context, opts cache.ListOptions) ([]cache.Entry, error) {
+	expiredBefore := c.expiredBefore()
+	rows, err := c.db.QueryContext(
+		ctx,
+		sqlList,
+		opts.AfterID,
+		opts.VideoIDPrefix,
+		opts.ExpiredOnly,
+		expiredBefore,
+		opts.FetchedAfter,
+		opts.FetchedBefore,
+		opts.Limit,
+	)
+	if err != nil {
+		return nil, internalError(ctx)
+	}
+	defer rows.Close()
+
+	entries := []cache.Entry{}
+	for rows.Next() {
+		var e cache.Entry
+		if err := rows.Scan(&e.ID, &e.VideoID, &e.Size, &e.TS); err != nil {
+			return nil, internalError(ctx)
+		}
+		e.Expired = e.TS < expiredBefore
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, internalError(ctx)
+	}
+	return entries, nil
+}
+
+// FindSimilar returns videos with thumbnails within maxDistance
+// (up to cache.MaxDistance) of hash, the closest first. Only entries
+// under plain video IDs and given variants are searched, expired ones are not.
+//
+// If hashes differ by at most maxDistance bits, at least one of their 4
+// chunks differs by at most maxDistance/4 bits. Indexed chunks are probed
+// with all values that close, candidates are checked with full hash.
+func (c *SQLiteCache) FindSimilar(
+	ctx context.Context,
+	hash uint64,
+	maxDistance int,
+	limit int,
+	variants []string,
+) ([]cache.Similar, error) {
+	maxDistance = min(maxDistance, cache.MaxDistance)
+	var (
+		where []string
+		args  []any
+	)
+	for i, chunk := range chunks(hash) {
+		probes := neighbours(chunk, maxDistance/4)
+		where = append(where, fmt.Sprintf("phash%d IN (?%s)", i, strings.Repeat(", ?", len(probes)-1)))
+		for _, p := range probes {
+			args = append(args, p)
+		}
+	}
+
+	query := "SELECT video_id, phash FROM thumbnail WHERE (" + strings.Join(where, " OR ") + ") AND ts >= ?"
+	args = append(args, c.expiredBefore())
+	// Not frames or transformed images
+	query += " AND (instr(video_id, '/') = 0"
+	if len(variants) > 0 {
+		query += " OR substr(video_id, instr(video_id, '/') + 1) IN (?" + strings.Repeat(", ?", len(variants)-1) + ")"
+		for _, v := range variants {
+			args = append(args, v)
+		}
+	}
+	query += ");"
+
+	rows, err := c.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, internalError(ctx)
+	}
+	defer rows.Close()
+
+	// Variants of one video are reported once with the closest distance
+	closest := map[string]int{}
+	for rows.Next() {
+		var (
+			key   string
+			other int64
+		)
+		if err := rows.Scan(&key, &other); err != nil {
+			return nil, internalError(ctx)
+		}
+		distance := bits.OnesCount64(hash ^ uint64(other))
+		if distance > maxDistance {
+			continue
+		}
+		videoID, _, _ := strings.Cut(key, "/")
+		if d, ok := closest[videoID]; !ok || distance < d {
+			closest[videoID] = distance
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, internalError(ctx)
+	}
+
+	similar := make([]cache.Similar, 0, len(closest))
+	for videoID, distance := range closest {
+		similar = append(similar, cache.Similar{VideoID: videoID, Distance: distance})
+	}
+	sort.Slice(similar, func(i, j int) bool {
+		if similar[i].Distance != similar[j].Distance {
+			return similar[i].Distance < similar[j].Distance
+		}
+		return similar[i].VideoID < similar[j].VideoID
+	})
+	if len(similar) > limit {
+		similar = similar[:limit]
+	}
+	return similar, nil
+}
+
+// chunks splits hash into 4 indexed 16-bit parts
+func chunks(hash uint64) [4]int64 {
+	var c [4]int64
+	for i := range c {
+		c[i] = int64(hash >> (16 * (3 - i)) & 0xffff)
+	}
+	return c
+}
+
+// neighbours returns all 16-bit values within distance bits of chunk
+func neighbours(chunk int64, distance int) []int64 {
+	values := []int64{chunk}
+	var flip func(value int64, from int, left int)
+	flip = func(value int64, from int, left int) {
+		for bit := from; bit < 16 && left > 0; bit++ {
+			v := value ^ 1<<bit
+			values = append(values, v)
+			flip(v, bit+1, left-1)
+		}
+	}
+	flip(chunk, 0, distance)
+	return values
+}
+
+// addColumn adds a column to thumbnail table unless it already exists
+func addColumn(ctx context.Context, db *sql.DB, name string, decl string) error {
+	rows, err := db.QueryContext(ctx, "SELECT name FROM pragma_table_info('thumbnail');")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return err
+		}
+		if column == name {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	_, err = db.ExecContext(ctx, "ALTER TABLE thumbnail ADD COLUMN "+name+" "+decl+";")
+	return err
+}
+
+// internalError keeps the cause if ctx is done, so timeouts of a request
+// can be told apart from failures of the cache itself
+func internalError(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("cache: %w", err)
+	}
+	return cache.ErrInternal
+}
+
+// expiredBefore is the unix time before which entries are expired
+func (c *SQLiteCache) expiredBefore() int64 {
+	return time.Now().Unix() - int64(c.TTL()/time.Second)
+}
+
+// Ping checks that database is still reachable
+func (c *SQLiteCache) Ping(ctx context.Context) error {
+	if err := c.db.PingContext(ctx); err != nil {
+		return internalError(ctx)
+	}
+	return nil
+}
+
 func (c *SQLiteCache) Close() {
 	c.insertStmt.Close()
 	c.selectStmt.Close()
@@ -3,7 +3,10 @@ package sqlite
 import (
 	"bytes"
 	"context"
+	"database/sql"
+	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -19,7 +22,7 @@ var c *SQLiteCache
 var b = []byte("test")
 
 func TestMain(m *testing.M) {
-	c, _ = New(ctx, ":memory:")
+	c, _ = New(ctx, ":memory:", 24*time.Hour)
 	code := m.Run()
 	c.Close()
 	os.Exit(code)
@@ -27,22 +30,213 @@ func TestMain(m *testing.M) {
 
 func TestSetGet(t *testing.T) {
 	id := "videoID1"
-	c.Set(ctx, id, b, time.Now().Unix())
-	r, err := c.Get(ctx, id)
+	c.Set(ctx, id, b, cache.Metadata{FetchedAt: time.Now().Unix()})
+	r, _, err := c.Get(ctx, id, 0)
 	assert.Nil(t, err)
 	assert.True(t, bytes.Equal(r, b))
 }
 
 func TestGetExpired(t *testing.T) {
 	id := "videoID2"
-	wantTS := time.Now().Unix() - exp - 10
-	c.Set(ctx, id, b, wantTS)
-	_, err := c.Get(ctx, id)
+	wantTS := time.Now().Add(-c.TTL()).Unix() - 10
+	c.Set(ctx, id, b, cache.Metadata{FetchedAt: wantTS})
+	_, _, err := c.Get(ctx, id, 0)
 	assert.ErrorIs(t, err, cache.ErrNotFound)
+	assert.ErrorIs(t, err, cache.ErrExpired)
 }
 
 func TestGetNotFound(t *testing.T) {
 	id := "videoID3"
-	_, err := c.Get(ctx, id)
+	_, _, err := c.Get(ctx, id, 0)
 	assert.ErrorIs(t, err, cache.ErrNotFound)
 }
+
+func TestContextError(t *testing.T) {
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	err := c.Set(canceled, "videoID8", b, cache.Metadata{FetchedAt: time.Now().Unix()})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NotErrorIs(t, err, cache.ErrInternal)
+}
+
+func TestPing(t *testing.T) {
+	assert.Nil(t, c.Ping(ctx))
+}
+
+func TestSetTTL(t *testing.T) {
+	id := "videoID4"
+	c.Set(ctx, id, b, cache.Metadata{FetchedAt: time.Now().Add(-time.Hour).Unix()})
+	_, _, err := c.Get(ctx, id, 0)
+	assert.Nil(t, err)
+
+	c.SetTTL(time.Minute)
+	defer c.SetTTL(24 * time.Hour)
+	_, _, err = c.Get(ctx, id, 0)
+	assert.ErrorIs(t, err, cache.ErrNotFound)
+}
+
+func TestInvalidate(t *testing.T) {
+	c, _ := New(ctx, ":memory:", time.Hour)
+	defer c.Close()
+	now := time.Now().Unix()
+	c.Set(ctx, "a_1", b, cache.Metadata{FetchedAt: now})
+	c.Set(ctx, "a_1/hqdefault", b, cache.Metadata{FetchedAt: now})
+	c.Set(ctx, "a_10", b, cache.Metadata{FetchedAt: now})
+	c.Set(ctx, "b", b, cache.Metadata{FetchedAt: now})
+
+	n, err := c.Invalidate(ctx, []string{"a_1", "b", "missing"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), n)
+
+	_, _, err = c.Get(ctx, "a_10", 0)
+	assert.Nil(t, err)
+}
+
+func TestPurgeAndStats(t *testing.T) {
+	c, _ := New(ctx, ":memory:", time.Hour)
+	defer c.Close()
+	old := time.Now().Add(-2 * time.Hour).Unix()
+	c.Set(ctx, "old", b, cache.Metadata{FetchedAt: old})
+	c.Set(ctx, "new", []byte("fresh"), cache.Metadata{FetchedAt: time.Now().Unix()})
+	c.Get(ctx, "old", 0)
+	c.Get(ctx, "new", 0)
+
+	stats, err := c.Stats(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, cache.Stats{Entries: 2, Bytes: 9, Hits: 1, Misses: 1, Oldest: old}, stats)
+
+	n, err := c.Purge(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+
+	stats, _ = c.Stats(ctx)
+	assert.Equal(t, int64(1), stats.Entries)
+}
+
+func TestList(t *testing.T) {
+	c, _ := New(ctx, ":memory:", time.Hour)
+	defer c.Close()
+	now := time.Now().Unix()
+	c.Set(ctx, "x1", b, cache.Metadata{FetchedAt: now})
+	c.Set(ctx, "x2", b, cache.Metadata{FetchedAt: now - 2*3600})
+	c.Set(ctx, "y1", b, cache.Metadata{FetchedAt: now})
+
+	page, err := c.List(ctx, cache.ListOptions{Limit: 2})
+	assert.Nil(t, err)
+	assert.Len(t, page, 2)
+	page, _ = c.List(ctx, cache.ListOptions{Limit: 2, AfterID: page[1].ID})
+	assert.Len(t, page, 1)
+	assert.Equal(t, "y1", page[0].VideoID)
+
+	page, _ = c.List(ctx, cache.ListOptions{Limit: 10, VideoIDPrefix: "x"})
+	assert.Len(t, page, 2)
+
+	page, _ = c.List(ctx, cache.ListOptions{Limit: 10, ExpiredOnly: true})
+	assert.Len(t, page, 1)
+	assert.Equal(t, cache.Entry{ID: 2, VideoID: "x2", Size: 4, TS: now - 2*3600, Expired: true}, page[0])
+}
+
+func TestSetReplaces(t *testing.T) {
+	id := "videoID5"
+	old := time.Now().Add(-48 * time.Hour).Unix()
+	c.Set(ctx, id, []byte("old"), cache.Metadata{FetchedAt: old})
+	now := time.Now().Unix()
+	c.Set(ctx, id, b, cache.Metadata{FetchedAt: now})
+
+	r, meta, err := c.Get(ctx, id, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, b, r)
+	assert.Equal(t, now, meta.FetchedAt)
+
+	entries, _ := c.List(ctx, cache.ListOptions{Limit: 10, VideoIDPrefix: id})
+	assert.Len(t, entries, 1)
+}
+
+func TestGetMaxAge(t *testing.T) {
+	id := "videoID6"
+	c.Set(ctx, id, b, cache.Metadata{FetchedAt: time.Now().Add(-time.Hour).Unix()})
+
+	_, _, err := c.Get(ctx, id, 2*time.Hour)
+	assert.Nil(t, err)
+	_, _, err = c.Get(ctx, id, time.Minute)
+	assert.ErrorIs(t, err, cache.ErrExpired)
+	// maxAge can't extend TTL
+	_, _, err = c.Get(ctx, id, 48*time.Hour)
+	assert.Nil(t, err)
+}
+
+func TestMetadata(t *testing.T) {
+	id := "videoID7"
+	want := cache.Metadata{
+		Width:       1280,
+		Height:      720,
+		ContentType: "image/jpeg",
+		Size:        int64(len(b)),
+		SHA256:      "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+		FetchedAt:   time.Now().Unix(),
+		SourceURL:   "https://i.ytimg.com/vi/videoID7/maxresdefault.jpg",
+		Variant:     "maxresdefault",
+
+		BlurHash:      "L9EySy4o?^xB_NM{IAIUyEIn-pIU",
+		DominantColor: "#1e1e1e",
+	}
+	c.Set(ctx, id, b, want)
+	_, meta, err := c.Get(ctx, id, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, want, meta)
+}
+
+func TestMigrateLegacyRows(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "legacy.db")
+	db, err := sql.Open("sqlite3", p)
+	assert.Nil(t, err)
+	_, err = db.Exec(`
+	CREATE TABLE thumbnail (id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT, video_id TEXT, data BLOB, ts INTEGER);
+	INSERT INTO thumbnail (video_id, data, ts) VALUES ('legacy', 'test', strftime('%s', 'now'));
+	`)
+	assert.Nil(t, err)
+	db.Close()
+
+	c, err := New(ctx, p, time.Hour)
+	assert.Nil(t, err)
+	defer c.Close()
+	r, meta, err := c.Get(ctx, "legacy", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, b, r)
+	assert.Equal(t, int64(len(b)), meta.Size)
+	assert.Empty(t, meta.ContentType)
+}
+
+func TestFindSimilar(t *testing.T) {
+	c, _ := New(ctx, ":memory:", time.Hour)
+	defer c.Close()
+	now := time.Now().Unix()
+	hash := uint64(0x0123456789abcdef)
+	set := func(key string, h uint64) {
+		c.Set(ctx, key, b, cache.Metadata{FetchedAt: now, PHash: fmt.Sprintf("%016x", h)})
+	}
+	set("same", hash)
+	set("near", hash^0b1011)
+	// Every chunk differs by 2 bits, found only by probing neighbours
+	set("spread", hash^0x0003000300030003)
+	set("near/hqdefault", hash^0b1)
+	set("far", ^hash)
+	c.Set(ctx, "nohash", b, cache.Metadata{FetchedAt: now})
+	// Not thumbnails or expired
+	set("frames/frame1", hash)
+	set("transformed/hqdefault/320x0-contain", hash)
+	c.Set(ctx, "old", b, cache.Metadata{FetchedAt: now - 2*3600, PHash: fmt.Sprintf("%016x", hash)})
+
+	similar, err := c.FindSimilar(ctx, hash, 8, 10, []string{"hqdefault"})
+	assert.Nil(t, err)
+	assert.Equal(t, []cache.Similar{
+		{VideoID: "same", Distance: 0},
+		{VideoID: "near", Distance: 1},
+		{VideoID: "spread", Distance: 8},
+	}, similar)
+
+	similar, _ = c.FindSimilar(ctx, hash, 2, 1, nil)
+	assert.Equal(t, []cache.Similar{{VideoID: "same", Distance: 0}}, similar)
+
+	assert.Len(t, neighbours(0, 2), 1+16+120)
+}
@@ -0,0 +1,272 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/pegov/yt-thumbnails-go/internal/downloader"
+	"github.com/pegov/yt-thumbnails-go/internal/imaging"
+)
+
+// Config is the server configuration. Values are resolved in order
+// defaults < config file < environment variables < flags.
+// Leaf fields are tagged with their env var name and flag name.
+// Fields tagged reload:"true" can be changed without a restart.
+type Config struct {
+	Log        LogConfig        `yaml:"log"`
+	AccessLog  AccessLogConfig  `yaml:"access_log"`
+	GRPC       GRPCConfig       `yaml:"grpc"`
+	HTTP       HTTPConfig       `yaml:"http"`
+	Cache      CacheConfig      `yaml:"cache"`
+	Downloader DownloaderConfig `yaml:"downloader"`
+	Limits     LimitsConfig     `yaml:"limits"`
+	Health     HealthConfig     `yaml:"health"`
+	TLS        TLSConfig        `yaml:"tls"`
+	Auth       AuthConfig       `yaml:"auth"`
+	Tracing    TracingConfig    `yaml:"tracing"`
+	Imaging    ImagingConfig    `yaml:"imaging"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL" flag:"log-level" reload:"true" usage:"log level (DEBUG, INFO, WARN, ERROR)"`
+	Format string `yaml:"format" env:"YT_THUMBNAILS_LOG_FORMAT" flag:"log-format" usage:"log format (text, json)"`
+	Output string `yaml:"output" env:"YT_THUMBNAILS_LOG_OUTPUT" flag:"log-output" usage:"log destination (stdout, stderr or file path)"`
+}
+
+type AccessLogConfig struct {
+	Enabled       bool    `yaml:"enabled" env:"YT_THUMBNAILS_ACCESS_LOG" flag:"access-log" usage:"write one access log line per RPC"`
+	ErrorsOnly    bool    `yaml:"errors_only" env:"YT_THUMBNAILS_ACCESS_LOG_ERRORS_ONLY" flag:"access-log-errors-only" usage:"log only failed RPCs"`
+	SamplePercent float64 `yaml:"sample_percent" env:"YT_THUMBNAILS_ACCESS_LOG_SAMPLE_PERCENT" flag:"access-log-sample-percent" usage:"percentage of successful RPCs to log (0-100)"`
+	Output        string  `yaml:"output" env:"YT_THUMBNAILS_ACCESS_LOG_OUTPUT" flag:"access-log-output" usage:"rotating access log file (empty - main log)"`
+	MaxSizeMB     int     `yaml:"max_size_mb" env:"YT_THUMBNAILS_ACCESS_LOG_MAX_SIZE_MB" flag:"access-log-max-size-mb" usage:"rotate access log file after this size"`
+	MaxBackups    int     `yaml:"max_backups" env:"YT_THUMBNAILS_ACCESS_LOG_MAX_BACKUPS" flag:"access-log-max-backups" usage:"rotated access log files to keep (0 - all)"`
+	MaxAgeDays    int     `yaml:"max_age_days" env:"YT_THUMBNAILS_ACCESS_LOG_MAX_AGE_DAYS" flag:"access-log-max-age-days" usage:"days to keep rotated access log files (0 - forever)"`
+}
+
+type GRPCConfig struct {
+	Addr       string `yaml:"addr" env:"YT_THUMBNAILS_ADDR" flag:"addr" usage:"address"`
+	Reflection bool   `yaml:"reflection" env:"YT_THUMBNAILS_REFLECTION" flag:"reflection" usage:"register server reflection service"`
+}
+
+type HTTPConfig struct {
+	Addr                  string   `yaml:"addr" env:"YT_THUMBNAILS_HTTP_ADDR" flag:"http-addr" usage:"HTTP gateway address (empty - disabled)"`
+	GRPCWeb               bool     `yaml:"grpc_web" env:"YT_THUMBNAILS_GRPC_WEB" flag:"grpc-web" usage:"serve gRPC-Web on HTTP gateway address"`
+	GRPCWebAllowedOrigins []string `yaml:"grpc_web_allowed_origins" env:"YT_THUMBNAILS_GRPC_WEB_ALLOWED_ORIGINS" flag:"grpc-web-allowed-origins" usage:"comma separated CORS origins allowed for gRPC-Web (* - any)"`
+	Metrics               bool     `yaml:"metrics" env:"YT_THUMBNAILS_HTTP_METRICS" flag:"http-metrics" usage:"serve expvar metrics at /debug/vars on HTTP gateway address"`
+}
+
+type CacheConfig struct {
+	Path string        `yaml:"path" env:"YT_THUMBNAILS_CACHE_PATH" flag:"cache-path" usage:"sqlite database file"`
+	TTL  time.Duration `yaml:"ttl" env:"YT_THUMBNAILS_CACHE_TTL" flag:"cache-ttl" reload:"true" usage:"how long cached thumbnails stay valid"`
+}
+
+type DownloaderConfig struct {
+	MaxParallelRequests     int           `yaml:"max_parallel_requests" env:"YT_THUMBNAILS_MAX_PARALLEL_HTTP_REQUESTS" flag:"max-parallel-http-requests" reload:"true" usage:"max parallel http requests to youtube"`
+	CircuitBreakerThreshold int           `yaml:"circuit_breaker_threshold" env:"YT_THUMBNAILS_CIRCUIT_BREAKER_THRESHOLD" flag:"circuit-breaker-threshold" usage:"consecutive upstream failures to open the circuit (0 - disabled)"`
+	CircuitBreakerCooldown  time.Duration `yaml:"circuit_breaker_cooldown" env:"YT_THUMBNAILS_CIRCUIT_BREAKER_COOLDOWN" flag:"circuit-breaker-cooldown" usage:"time before probing upstream again after the circuit opens"`
+	MinImageBytes           int64         `yaml:"min_image_bytes" env:"YT_THUMBNAILS_MIN_IMAGE_BYTES" flag:"min-image-bytes" usage:"reject smaller upstream images"`
+	MaxImageBytes           int64         `yaml:"max_image_bytes" env:"YT_THUMBNAILS_MAX_IMAGE_BYTES" flag:"max-image-bytes" usage:"reject larger upstream images (0 - no limit)"`
+	DialTimeout             time.Duration `yaml:"dial_timeout" env:"YT_THUMBNAILS_UPSTREAM_DIAL_TIMEOUT" flag:"upstream-dial-timeout" usage:"upstream TCP connect timeout"`
+	TLSHandshakeTimeout     time.Duration `yaml:"tls_handshake_timeout" env:"YT_THUMBNAILS_UPSTREAM_TLS_HANDSHAKE_TIMEOUT" flag:"upstream-tls-handshake-timeout" usage:"upstream TLS handshake timeout"`
+	ResponseHeaderTimeout   time.Duration `yaml:"response_header_timeout" env:"YT_THUMBNAILS_UPSTREAM_RESPONSE_HEADER_TIMEOUT" flag:"upstream-response-header-timeout" usage:"time to wait for upstream response headers"`
+	IdleConnTimeout         time.Duration `yaml:"idle_conn_timeout" env:"YT_THUMBNAILS_UPSTREAM_IDLE_CONN_TIMEOUT" flag:"upstream-idle-conn-timeout" usage:"how long idle upstream connections are kept"`
+	MaxIdleConnsPerHost     int           `yaml:"max_idle_conns_per_host" env:"YT_THUMBNAILS_UPSTREAM_MAX_IDLE_CONNS_PER_HOST" flag:"upstream-max-idle-conns-per-host" usage:"idle upstream connections kept per host"`
+	HTTP2                   bool          `yaml:"http2" env:"YT_THUMBNAILS_UPSTREAM_HTTP2" flag:"upstream-http2" usage:"use HTTP/2 for upstream requests"`
+	ProxyURL                string        `yaml:"proxy_url" env:"YT_THUMBNAILS_UPSTREAM_PROXY_URL" flag:"upstream-proxy-url" usage:"proxy for upstream requests (empty - from environment, direct - no proxy)"`
+	UserAgent               string        `yaml:"user_agent" env:"YT_THUMBNAILS_UPSTREAM_USER_AGENT" flag:"upstream-user-agent" usage:"User-Agent of upstream requests"`
+	Headers                 []string      `yaml:"headers" env:"YT_THUMBNAILS_UPSTREAM_HEADERS" flag:"upstream-headers" usage:"comma separated extra upstream request headers (Name: Value)"`
+}
+
+type LimitsConfig struct {
+	RequestTimeout  time.Duration `yaml:"request_timeout" env:"YT_THUMBNAILS_REQUEST_TIMEOUT" flag:"request-timeout" reload:"true" usage:"timeout for cache and upstream requests of one call"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"YT_THUMBNAILS_SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" reload:"true" usage:"graceful shutdown timeout"`
+}
+
+type HealthConfig struct {
+	Enabled       bool          `yaml:"enabled" env:"YT_THUMBNAILS_HEALTH" flag:"health" usage:"register grpc.health.v1.Health service"`
+	Interval      time.Duration `yaml:"interval" env:"YT_THUMBNAILS_HEALTH_INTERVAL" flag:"health-interval" usage:"health check interval"`
+	CheckCache    bool          `yaml:"check_cache" env:"YT_THUMBNAILS_HEALTH_CHECK_CACHE" flag:"health-check-cache" usage:"report NOT_SERVING if sqlite cache is unreachable"`
+	CheckCircuit  bool          `yaml:"check_circuit" env:"YT_THUMBNAILS_HEALTH_CHECK_CIRCUIT" flag:"health-check-circuit" usage:"report NOT_SERVING if circuit to upstream is open"`
+	CheckDraining bool          `yaml:"check_draining" env:"YT_THUMBNAILS_HEALTH_CHECK_DRAINING" flag:"health-check-draining" usage:"report NOT_SERVING during graceful shutdown"`
+}
+
+type TLSConfig struct {
+	Cert           string        `yaml:"cert" env:"YT_THUMBNAILS_TLS_CERT" flag:"tls-cert" usage:"server certificate file (empty - plaintext)"`
+	Key            string        `yaml:"key" env:"YT_THUMBNAILS_TLS_KEY" flag:"tls-key" usage:"server private key file"`
+	ClientCA       string        `yaml:"client_ca" env:"YT_THUMBNAILS_TLS_CLIENT_CA" flag:"tls-client-ca" usage:"CA file to verify client certificates (mTLS)"`
+	ReloadInterval time.Duration `yaml:"reload_interval" env:"YT_THUMBNAILS_TLS_RELOAD_INTERVAL" flag:"tls-reload-interval" usage:"how often to check certificate files for changes"`
+}
+
+type AuthConfig struct {
+	KeysFile       string        `yaml:"keys_file" env:"YT_THUMBNAILS_AUTH_KEYS_FILE" flag:"auth-keys-file" usage:"JSON file with API keys and their limits (empty - no authentication)"`
+	AdminKeysFile  string        `yaml:"admin_keys_file" env:"YT_THUMBNAILS_AUTH_ADMIN_KEYS_FILE" flag:"auth-admin-keys-file" usage:"JSON file with admin keys (empty - AdminService disabled)"`
+	ReloadInterval time.Duration `yaml:"reload_interval" env:"YT_THUMBNAILS_AUTH_RELOAD_INTERVAL" flag:"auth-reload-interval" usage:"how often to check API keys file for changes"`
+}
+
+type TracingConfig struct {
+	Exporter string `yaml:"exporter" env:"YT_THUMBNAILS_OTEL_EXPORTER" flag:"otel-exporter" usage:"trace exporter (none, otlp, stdout)"`
+	Endpoint string `yaml:"endpoint" env:"YT_THUMBNAILS_OTEL_ENDPOINT" flag:"otel-endpoint" usage:"OTLP gRPC collector endpoint"`
+	Insecure bool   `yaml:"insecure" env:"YT_THUMBNAILS_OTEL_INSECURE" flag:"otel-insecure" usage:"disable TLS for OTLP exporter"`
+}
+
+type ImagingConfig struct {
+	CropLetterbox         bool     `yaml:"crop_letterbox" env:"YT_THUMBNAILS_CROP_LETTERBOX" flag:"crop-letterbox" reload:"true" usage:"crop black letterbox bars unless request says otherwise"`
+	MaxParallelTransforms int      `yaml:"max_parallel_transforms" env:"YT_THUMBNAILS_MAX_PARALLEL_TRANSFORMS" flag:"max-parallel-transforms" reload:"true" usage:"max images resized or re-encoded at once"`
+	Sizes                 []string `yaml:"sizes" env:"YT_THUMBNAILS_IMAGING_SIZES" flag:"imaging-sizes" reload:"true" usage:"comma separated WxH sizes allowed for resizing (empty - any)"`
+}
+
+func Default() *Config {
+	return &Config{
+		Log: LogConfig{
+			Level:  "INFO",
+			Format: "text",
+			Output: "stdout",
+		},
+		AccessLog: AccessLogConfig{
+			Enabled:       true,
+			SamplePercent: 100,
+			MaxSizeMB:     100,
+			MaxBackups:    10,
+			MaxAgeDays:    30,
+		},
+		GRPC: GRPCConfig{
+			Addr:       "localhost:8080",
+			Reflection: true,
+		},
+		HTTP: HTTPConfig{GRPCWebAllowedOrigins: []string{}},
+		Cache: CacheConfig{
+			Path: "./thumbnail.db",
+			TTL:  24 * time.Hour,
+		},
+		Downloader: DownloaderConfig{
+			MaxParallelRequests:     16,
+			CircuitBreakerThreshold: 5,
+			CircuitBreakerCooldown:  30 * time.Second,
+			MinImageBytes:           100,
+			MaxImageBytes:           5 << 20,
+			DialTimeout:             5 * time.Second,
+			TLSHandshakeTimeout:     5 * time.Second,
+			ResponseHeaderTimeout:   5 * time.Second,
+			IdleConnTimeout:         90 * time.Second,
+			MaxIdleConnsPerHost:     32,
+			HTTP2:                   true,
+			UserAgent:               "yt-thumbnails-go",
+			Headers:                 []string{},
+		},
+		Limits: LimitsConfig{
+			RequestTimeout:  5 * time.Second,
+			ShutdownTimeout: 10 * time.Second,
+		},
+		Health: HealthConfig{
+			Enabled:       true,
+			Interval:      5 * time.Second,
+			CheckCache:    true,
+			CheckCircuit:  true,
+			CheckDraining: true,
+		},
+		Imaging: ImagingConfig{
+			MaxParallelTransforms: runtime.NumCPU(),
+			Sizes:                 []string{},
+		},
+		TLS:  TLSConfig{ReloadInterval: 10 * time.Second},
+		Auth: AuthConfig{ReloadInterval: 10 * time.Second},
+		Tracing: TracingConfig{
+			Exporter: "none",
+			Endpoint: "localhost:4317",
+			Insecure: true,
+		},
+	}
+}
+
+// Validate checks config for values that would fail at startup
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	switch c.Log.Level {
+	case "DEBUG", "INFO", "WARN", "ERROR":
+	default:
+		check(false, "log.level: unknown level %q", c.Log.Level)
+	}
+
+	switch c.Log.Format {
+	case "text", "json":
+	default:
+		check(false, "log.format: unknown format %q", c.Log.Format)
+	}
+	check(c.Log.Output != "", "log.output: required")
+
+	check(
+		c.AccessLog.SamplePercent >= 0 && c.AccessLog.SamplePercent <= 100,
+		"access_log.sample_percent: must be between 0 and 100",
+	)
+	check(c.AccessLog.Output == "" || c.AccessLog.MaxSizeMB > 0, "access_log.max_size_mb: must be positive")
+
+	check(c.GRPC.Addr != "", "grpc.addr: required")
+	check(!c.HTTP.GRPCWeb || c.HTTP.Addr != "", "http.grpc_web: requires http.addr")
+
+	check(c.Cache.Path != "", "cache.path: required")
+	check(c.Cache.TTL > 0, "cache.ttl: must be positive")
+
+	check(c.Downloader.MaxParallelRequests > 0, "downloader.max_parallel_requests: must be positive")
+	check(c.Downloader.CircuitBreakerThreshold >= 0, "downloader.circuit_breaker_threshold: must not be negative")
+	check(c.Downloader.MinImageBytes >= 0, "downloader.min_image_bytes: must not be negative")
+	check(c.Downloader.MaxIdleConnsPerHost >= 0, "downloader.max_idle_conns_per_host: must not be negative")
+	if _, err := downloader.NewHTTPClient(downloader.ClientConfig{ProxyURL: c.Downloader.ProxyURL}); err != nil {
+		check(false, "downloader.proxy_url: %v", err)
+	}
+	if _, err := downloader.ParseHeaders(c.Downloader.Headers); err != nil {
+		check(false, "downloader.headers: %v", err)
+	}
+	check(
+		c.Downloader.MaxImageBytes == 0 || c.Downloader.MaxImageBytes >= c.Downloader.MinImageBytes,
+		"downloader.max_image_bytes: must not be less than min_image_bytes",
+	)
+	check(
+		c.Downloader.CircuitBreakerThreshold == 0 || c.Downloader.CircuitBreakerCooldown > 0,
+		"downloader.circuit_breaker_cooldown: must be positive",
+	)
+
+	check(c.Imaging.MaxParallelTransforms > 0, "imaging.max_parallel_transforms: must be positive")
+	if _, err := imaging.ParseSizes(c.Imaging.Sizes); err != nil {
+		check(false, "imaging.sizes: %v", err)
+	}
+
+	check(c.Limits.RequestTimeout > 0, "limits.request_timeout: must be positive")
+	check(c.Limits.ShutdownTimeout > 0, "limits.shutdown_timeout: must be positive")
+
+	check(!c.Health.Enabled || c.Health.Interval > 0, "health.interval: must be positive")
+
+	check((c.TLS.Cert == "") == (c.TLS.Key == ""), "tls: cert and key must be set together")
+	check(c.TLS.ClientCA == "" || c.TLS.Cert != "", "tls.client_ca: requires tls.cert")
+	check(c.TLS.Cert == "" || c.TLS.ReloadInterval > 0, "tls.reload_interval: must be positive")
+
+	check((c.Auth.KeysFile == "" && c.Auth.AdminKeysFile == "") || c.Auth.ReloadInterval > 0, "auth.reload_interval: must be positive")
+
+	switch c.Tracing.Exporter {
+	case "none", "otlp", "stdout":
+	default:
+		check(false, "tracing.exporter: unknown exporter %q", c.Tracing.Exporter)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Write prints config as YAML
+func (c *Config) Write(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return err
+	}
+	return enc.Close()
+}
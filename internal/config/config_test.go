@@ -0,0 +1,134 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const configFile = `
+grpc:
+  addr: 0.0.0.0:9000
+cache:
+  path: /var/lib/thumbnails/thumbnail.db
+  ttl: 12h
+downloader:
+  max_parallel_requests: 32
+http:
+  grpc_web_allowed_origins: [https://a.example.com, https://b.example.com]
+`
+
+func load(t *testing.T, args []string, env map[string]string) (*Config, error) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return Load(fs, args, func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	})
+}
+
+func writeConfig(t *testing.T, content string) string {
+	p := filepath.Join(t.TempDir(), "config.yaml")
+	assert.Nil(t, os.WriteFile(p, []byte(content), 0600))
+	return p
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := load(t, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, Default(), cfg)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	p := writeConfig(t, configFile)
+
+	cfg, err := load(t, []string{"-config", p}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "0.0.0.0:9000", cfg.GRPC.Addr)
+	assert.Equal(t, 12*time.Hour, cfg.Cache.TTL)
+	assert.Equal(t, 32, cfg.Downloader.MaxParallelRequests)
+	assert.Equal(t, []string{"https://a.example.com", "https://b.example.com"}, cfg.HTTP.GRPCWebAllowedOrigins)
+	// Not set in file
+	assert.Equal(t, 5*time.Second, cfg.Limits.RequestTimeout)
+
+	env := map[string]string{
+		"YT_THUMBNAILS_CONFIG":                     p,
+		"YT_THUMBNAILS_MAX_PARALLEL_HTTP_REQUESTS": "64",
+		"YT_THUMBNAILS_CACHE_TTL":                  "1h",
+		"LOG_LEVEL":                                "DEBUG",
+	}
+	cfg, err = load(t, []string{"-cache-ttl=30m"}, env)
+	assert.Nil(t, err)
+	assert.Equal(t, "0.0.0.0:9000", cfg.GRPC.Addr)
+	assert.Equal(t, 64, cfg.Downloader.MaxParallelRequests)
+	assert.Equal(t, 30*time.Minute, cfg.Cache.TTL)
+	assert.Equal(t, "DEBUG", cfg.Log.Level)
+}
+
+func TestLoadBoolFlag(t *testing.T) {
+	cfg, err := load(t, []string{"-reflection=false", "-grpc-web", "-http-addr", ":8081"}, nil)
+	assert.Nil(t, err)
+	assert.False(t, cfg.GRPC.Reflection)
+	assert.True(t, cfg.HTTP.GRPCWeb)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	_, err := load(t, []string{"-grpc-web"}, nil)
+	assert.ErrorContains(t, err, "http.grpc_web")
+
+	_, err = load(t, nil, map[string]string{"YT_THUMBNAILS_CACHE_TTL": "day"})
+	assert.ErrorContains(t, err, "YT_THUMBNAILS_CACHE_TTL")
+
+	_, err = load(t, []string{"-max-parallel-http-requests=many"}, nil)
+	assert.NotNil(t, err)
+
+	_, err = load(t, []string{"-config", writeConfig(t, "cache:\n  size: 10\n")}, nil)
+	assert.ErrorContains(t, err, "size")
+
+	_, err = load(t, []string{"-log-level=TRACE", "-cache-ttl=0s"}, nil)
+	assert.ErrorContains(t, err, "log.level")
+	assert.ErrorContains(t, err, "cache.ttl")
+
+	_, err = load(t, []string{"-upstream-proxy-url=localhost", "-upstream-headers=X-Debug"}, nil)
+	assert.ErrorContains(t, err, "downloader.proxy_url")
+	assert.ErrorContains(t, err, "downloader.headers")
+
+	_, err = load(t, []string{"-imaging-sizes=320", "-max-parallel-transforms=0"}, nil)
+	assert.ErrorContains(t, err, "imaging.sizes")
+	assert.ErrorContains(t, err, "imaging.max_parallel_transforms")
+}
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, Default().Write(&buf))
+	assert.Contains(t, buf.String(), "ttl: 24h0m0s")
+
+	p := writeConfig(t, buf.String())
+	cfg, err := load(t, []string{"-config", p}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, Default(), cfg)
+}
+
+func TestDiff(t *testing.T) {
+	next := Default()
+	next.Cache.TTL = time.Hour
+	next.Log.Level = "DEBUG"
+
+	changes := Default().Diff(next)
+	assert.Equal(t, []Change{
+		{Key: "log.level", Old: "INFO", New: "DEBUG", Reload: true},
+		{Key: "cache.ttl", Old: "24h0m0s", New: "1h0m0s", Reload: true},
+	}, changes)
+	assert.Nil(t, CheckReload(changes))
+
+	next.GRPC.Addr = "0.0.0.0:9000"
+	next.HTTP.GRPCWebAllowedOrigins = []string{"*"}
+	err := CheckReload(Default().Diff(next))
+	assert.ErrorContains(t, err, "grpc.addr, http.grpc_web_allowed_origins")
+}
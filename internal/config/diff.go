@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Change is one setting that differs between two configs
+type Change struct {
+	Key    string
+	Old    string
+	New    string
+	Reload bool
+}
+
+// Diff lists settings that differ in next compared to c
+func (c *Config) Diff(next *Config) []Change {
+	var changes []Change
+	oldValue := reflect.ValueOf(c).Elem()
+	newValue := reflect.ValueOf(next).Elem()
+	for _, opt := range options(oldValue.Type(), nil, "") {
+		o := oldValue.FieldByIndex(opt.index)
+		n := newValue.FieldByIndex(opt.index)
+		if format(o) == format(n) {
+			continue
+		}
+		changes = append(changes, Change{
+			Key:    opt.key,
+			Old:    format(o),
+			New:    format(n),
+			Reload: opt.reload,
+		})
+	}
+	return changes
+}
+
+// CheckReload returns error listing changed settings that need a restart
+func CheckReload(changes []Change) error {
+	var keys []string
+	for _, change := range changes {
+		if !change.Reload {
+			keys = append(keys, change.Key)
+		}
+	}
+	if len(keys) > 0 {
+		return fmt.Errorf("%s: requires restart", strings.Join(keys, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const configFileEnv = "YT_THUMBNAILS_CONFIG"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// option is one leaf field of Config
+type option struct {
+	index []int
+	// key is dotted yaml path (e.g. "cache.ttl")
+	key    string
+	env    string
+	flag   string
+	usage  string
+	reload bool
+}
+
+// flagValue remembers raw flag value to apply it after file and env
+type flagValue struct {
+	index []int
+	field reflect.Value
+	raw   string
+}
+
+func (v *flagValue) String() string {
+	if !v.field.IsValid() {
+		return ""
+	}
+	return format(v.field)
+}
+
+func (v *flagValue) Set(s string) error {
+	// Fail on bad values right away, flag package reports them
+	if err := parse(reflect.New(v.field.Type()).Elem(), s); err != nil {
+		return err
+	}
+	v.raw = s
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool {
+	return v.field.Kind() == reflect.Bool
+}
+
+// Load registers config flags (and -config) on fs, parses args
+// and resolves values: defaults < config file < env < flags.
+// Config file is taken from -config flag or YT_THUMBNAILS_CONFIG env var.
+func Load(
+	fs *flag.FlagSet,
+	args []string,
+	lookupEnv func(string) (string, bool),
+) (*Config, error) {
+	cfg := Default()
+	defaults := reflect.ValueOf(cfg).Elem()
+
+	opts := options(reflect.TypeOf(*cfg), nil, "")
+	values := make(map[string]*flagValue, len(opts))
+	for _, opt := range opts {
+		v := &flagValue{index: opt.index, field: defaults.FieldByIndex(opt.index)}
+		values[opt.flag] = v
+		fs.Var(v, opt.flag, fmt.Sprintf("%s (env %s)", opt.usage, opt.env))
+	}
+	configFile := fs.String("config", "", fmt.Sprintf("YAML config file (env %s)", configFileEnv))
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	// Defaults were only needed for flag help
+	cfg = Default()
+	v := reflect.ValueOf(cfg).Elem()
+
+	path := *configFile
+	if path == "" {
+		path, _ = lookupEnv(configFileEnv)
+	}
+	if path != "" {
+		if err := loadFile(cfg, path); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, opt := range opts {
+		s, ok := lookupEnv(opt.env)
+		if !ok {
+			continue
+		}
+		if err := parse(v.FieldByIndex(opt.index), s); err != nil {
+			return nil, fmt.Errorf("env %s: %w", opt.env, err)
+		}
+	}
+
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		fv, ok := values[f.Name]
+		if !ok || err != nil {
+			return
+		}
+		err = parse(v.FieldByIndex(fv.index), fv.raw)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func loadFile(cfg *Config, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config %s: %w", path, err)
+	}
+	return nil
+}
+
+func options(t reflect.Type, index []int, prefix string) []option {
+	var opts []option
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		idx := append(append([]int{}, index...), i)
+		key := prefix + field.Tag.Get("yaml")
+		if field.Type.Kind() == reflect.Struct {
+			opts = append(opts, options(field.Type, idx, key+".")...)
+			continue
+		}
+		opts = append(opts, option{
+			index:  idx,
+			key:    key,
+			env:    field.Tag.Get("env"),
+			flag:   field.Tag.Get("flag"),
+			usage:  field.Tag.Get("usage"),
+			reload: field.Tag.Get("reload") == "true",
+		})
+	}
+	return opts
+}
+
+func parse(field reflect.Value, s string) error {
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported config type %v", field.Type())
+	}
+	return nil
+}
+
+func format(field reflect.Value) string {
+	if field.Type() == durationType {
+		return time.Duration(field.Int()).String()
+	}
+	if field.Kind() == reflect.Slice {
+		return strings.Join(field.Interface().([]string), ",")
+	}
+	return fmt.Sprint(field.Interface())
+}
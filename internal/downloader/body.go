@@ -0,0 +1,47 @@
+package downloader
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// Buffers grown beyond this are dropped instead of returned to the pool
+const maxPooledBufferSize = 1 << 20
+
+var bufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// readBody reads at most maxSize bytes (0 - no limit) into a pooled buffer
+// pre-sized from Content-Length (-1 if unknown) and returns a copy of them.
+func readBody(r io.Reader, contentLength int64, maxSize int64) ([]byte, error) {
+	if maxSize > 0 && contentLength > maxSize {
+		return nil, ErrTooLarge
+	}
+
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer func() {
+		if buf.Cap() <= maxPooledBufferSize {
+			bufferPool.Put(buf)
+		}
+	}()
+
+	// Without a limit Content-Length is not trusted for large allocations
+	if contentLength > 0 && (maxSize > 0 || contentLength <= maxPooledBufferSize) {
+		// ReadFrom needs MinRead free bytes to see EOF without growing
+		buf.Grow(int(contentLength) + bytes.MinRead)
+	}
+	if maxSize > 0 {
+		r = io.LimitReader(r, maxSize+1)
+	}
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, ErrCouldNotReadBody
+	}
+	if maxSize > 0 && int64(buf.Len()) > maxSize {
+		return nil, ErrTooLarge
+	}
+
+	return bytes.Clone(buf.Bytes()), nil
+}
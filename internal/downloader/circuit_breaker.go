@@ -0,0 +1,149 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+type thumbnailDownloader interface {
+	DownloadThumbnail(ctx context.Context, videoID string) (*Thumbnail, error)
+	DownloadVariant(ctx context.Context, videoID string, variant string) (*Thumbnail, error)
+	DownloadFrame(ctx context.Context, videoID string, index int) (*Thumbnail, error)
+	HeadVariant(ctx context.Context, videoID string, variant string) (*Head, error)
+}
+
+// CircuitBreaker stops sending requests upstream after threshold consecutive
+// failures. After cooldown one request is let through to probe upstream,
+// the others are rejected until it succeeds.
+type CircuitBreaker struct {
+	downloader thumbnailDownloader
+	threshold  int
+	cooldown   time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func NewCircuitBreaker(
+	downloader thumbnailDownloader,
+	threshold int,
+	cooldown time.Duration,
+) *CircuitBreaker {
+	return &CircuitBreaker{
+		downloader: downloader,
+		threshold:  threshold,
+		cooldown:   cooldown,
+	}
+}
+
+func (cb *CircuitBreaker) DownloadThumbnail(
+	ctx context.Context,
+	videoID string,
+) (*Thumbnail, error) {
+	probe, ok := cb.allow()
+	if !ok {
+		return nil, ErrCircuitOpen
+	}
+
+	t, err := cb.downloader.DownloadThumbnail(ctx, videoID)
+	cb.record(err, probe)
+	return t, err
+}
+
+func (cb *CircuitBreaker) DownloadVariant(
+	ctx context.Context,
+	videoID string,
+	variant string,
+) (*Thumbnail, error) {
+	probe, ok := cb.allow()
+	if !ok {
+		return nil, ErrCircuitOpen
+	}
+
+	t, err := cb.downloader.DownloadVariant(ctx, videoID, variant)
+	cb.record(err, probe)
+	return t, err
+}
+
+func (cb *CircuitBreaker) DownloadFrame(
+	ctx context.Context,
+	videoID string,
+	index int,
+) (*Thumbnail, error) {
+	probe, ok := cb.allow()
+	if !ok {
+		return nil, ErrCircuitOpen
+	}
+
+	t, err := cb.downloader.DownloadFrame(ctx, videoID, index)
+	cb.record(err, probe)
+	return t, err
+}
+
+func (cb *CircuitBreaker) HeadVariant(
+	ctx context.Context,
+	videoID string,
+	variant string,
+) (*Head, error) {
+	probe, ok := cb.allow()
+	if !ok {
+		return nil, ErrCircuitOpen
+	}
+
+	h, err := cb.downloader.HeadVariant(ctx, videoID, variant)
+	cb.record(err, probe)
+	return h, err
+}
+
+// IsOpen reports whether requests to upstream are currently rejected,
+// during cooldown and while the probe is in flight
+func (cb *CircuitBreaker) IsOpen() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.failures >= cb.threshold && (cb.probing || time.Since(cb.openedAt) < cb.cooldown)
+}
+
+// allow reports whether request may go upstream and whether it is
+// the probe, only one request takes the probe slot after cooldown
+func (cb *CircuitBreaker) allow() (probe bool, ok bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.failures < cb.threshold {
+		return false, true
+	}
+	if cb.probing || time.Since(cb.openedAt) < cb.cooldown {
+		return false, false
+	}
+	cb.probing = true
+	return true, true
+}
+
+func (cb *CircuitBreaker) record(err error, probe bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	// Requests started before the circuit opened don't free the slot
+	if probe {
+		cb.probing = false
+	}
+
+	// Not found and canceled requests say nothing about upstream health
+	if err == nil ||
+		errors.Is(err, ErrNotFound) ||
+		errors.Is(err, ErrInvalidVariant) ||
+		errors.Is(err, context.Canceled) {
+		cb.failures = 0
+		return
+	}
+
+	// Failed probe opens the circuit for another cooldown
+	cb.failures++
+	if cb.failures >= cb.threshold {
+		cb.openedAt = time.Now()
+	}
+}
@@ -0,0 +1,134 @@
+package downloader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeDownloader struct {
+	err error
+	// started and release block DownloadThumbnail if set
+	started chan struct{}
+	release chan struct{}
+}
+
+func (d *fakeDownloader) DownloadThumbnail(
+	ctx context.Context,
+	videoID string,
+) (*Thumbnail, error) {
+	if d.release != nil {
+		d.started <- struct{}{}
+		<-d.release
+	}
+	return nil, d.err
+}
+
+func (d *fakeDownloader) DownloadVariant(
+	ctx context.Context,
+	videoID string,
+	variant string,
+) (*Thumbnail, error) {
+	return nil, d.err
+}
+
+func (d *fakeDownloader) DownloadFrame(
+	ctx context.Context,
+	videoID string,
+	index int,
+) (*Thumbnail, error) {
+	return nil, d.err
+}
+
+func (d *fakeDownloader) HeadVariant(
+	ctx context.Context,
+	videoID string,
+	variant string,
+) (*Head, error) {
+	return nil, d.err
+}
+
+func TestCircuitBreakerOpens(t *testing.T) {
+	fake := &fakeDownloader{err: ErrTimeout}
+	cb := NewCircuitBreaker(fake, 3, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		_, err := cb.DownloadThumbnail(context.Background(), videoIDMaxRes)
+		assert.ErrorIs(t, err, ErrTimeout)
+	}
+	assert.True(t, cb.IsOpen())
+
+	_, err := cb.DownloadThumbnail(context.Background(), videoIDMaxRes)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+}
+
+func TestCircuitBreakerNotFound(t *testing.T) {
+	fake := &fakeDownloader{err: ErrNotFound}
+	cb := NewCircuitBreaker(fake, 1, time.Hour)
+
+	_, err := cb.DownloadThumbnail(context.Background(), videoIDMaxRes)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.False(t, cb.IsOpen())
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	fake := &fakeDownloader{err: ErrServerError}
+	cb := NewCircuitBreaker(fake, 1, 10*time.Millisecond)
+
+	cb.DownloadThumbnail(context.Background(), videoIDMaxRes)
+	assert.True(t, cb.IsOpen())
+
+	time.Sleep(20 * time.Millisecond)
+	assert.False(t, cb.IsOpen())
+
+	fake.err = nil
+	_, err := cb.DownloadThumbnail(context.Background(), videoIDMaxRes)
+	assert.Nil(t, err)
+	assert.False(t, cb.IsOpen())
+}
+
+func TestCircuitBreakerSingleProbe(t *testing.T) {
+	fake := &fakeDownloader{err: ErrServerError}
+	cb := NewCircuitBreaker(fake, 1, 10*time.Millisecond)
+
+	cb.DownloadThumbnail(context.Background(), videoIDMaxRes)
+	time.Sleep(20 * time.Millisecond)
+
+	fake.err = nil
+	fake.started = make(chan struct{})
+	fake.release = make(chan struct{})
+	done := make(chan error)
+	go func() {
+		_, err := cb.DownloadThumbnail(context.Background(), videoIDMaxRes)
+		done <- err
+	}()
+	<-fake.started
+
+	// Only the probe goes upstream until it is done
+	assert.True(t, cb.IsOpen())
+	_, err := cb.DownloadVariant(context.Background(), videoIDMaxRes, VariantHq)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	close(fake.release)
+	assert.Nil(t, <-done)
+	assert.False(t, cb.IsOpen())
+	_, err = cb.DownloadVariant(context.Background(), videoIDMaxRes, VariantHq)
+	assert.Nil(t, err)
+}
+
+func TestCircuitBreakerFailedProbe(t *testing.T) {
+	fake := &fakeDownloader{err: ErrServerError}
+	cb := NewCircuitBreaker(fake, 1, 10*time.Millisecond)
+
+	cb.DownloadThumbnail(context.Background(), videoIDMaxRes)
+	time.Sleep(20 * time.Millisecond)
+
+	_, err := cb.DownloadThumbnail(context.Background(), videoIDMaxRes)
+	assert.ErrorIs(t, err, ErrServerError)
+	// Another cooldown
+	assert.True(t, cb.IsOpen())
+	_, err = cb.DownloadThumbnail(context.Background(), videoIDMaxRes)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+}
@@ -0,0 +1,81 @@
+package downloader
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ProxyDirect disables proxy, including one from environment
+const ProxyDirect = "direct"
+
+var (
+	ErrInvalidProxyURL = errors.New("invalid proxy url")
+	ErrInvalidHeader   = errors.New("invalid header, want \"Name: Value\"")
+)
+
+type ClientConfig struct {
+	DialTimeout           time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	IdleConnTimeout       time.Duration
+	MaxIdleConnsPerHost   int
+	HTTP2                 bool
+	// ProxyURL is empty for proxy from environment or ProxyDirect
+	ProxyURL string
+}
+
+// NewHTTPClient creates client for upstream requests. Overall request
+// timeout comes from request context.
+func NewHTTPClient(cfg ClientConfig) (*http.Client, error) {
+	proxy := http.ProxyFromEnvironment
+	switch cfg.ProxyURL {
+	case "":
+	case ProxyDirect:
+		proxy = nil
+	default:
+		u, err := url.Parse(cfg.ProxyURL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, ErrInvalidProxyURL
+		}
+		proxy = http.ProxyURL(u)
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   cfg.DialTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+	transport := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: cfg.ResponseHeaderTimeout,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		ForceAttemptHTTP2:     cfg.HTTP2,
+	}
+	if !cfg.HTTP2 {
+		// Non-nil empty map disables HTTP/2
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	return &http.Client{Transport: transport}, nil
+}
+
+// ParseHeaders parses "Name: Value" strings
+func ParseHeaders(lines []string) (http.Header, error) {
+	header := make(http.Header)
+	for _, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, ErrInvalidHeader
+		}
+		header.Add(name, strings.TrimSpace(value))
+	}
+	return header, nil
+}
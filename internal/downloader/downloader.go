@@ -1,6 +1,9 @@
 package downloader
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrServerError           = errors.New("server error")
@@ -10,4 +13,30 @@ var (
 	ErrTimeout               = errors.New("timeout")
 	ErrCouldNotReadBody      = errors.New("error reading the body")
 	ErrCouldNotUnmarshalBody = errors.New("error unmarshaling the body")
+	ErrCircuitOpen           = errors.New("circuit open")
+	ErrInvalidVariant        = errors.New("invalid variant")
+	ErrInvalidImage          = errors.New("invalid image")
+	ErrTooLarge              = errors.New("response body too large")
+	// ErrPlaceholder is ErrNotFound for a generic image served instead of 404
+	ErrPlaceholder = fmt.Errorf("placeholder: %w", ErrNotFound)
 )
+
+// Thumbnail is a downloaded image and where it came from
+type Thumbnail struct {
+	Data []byte
+	// Variant actually downloaded, may differ from requested after fallback
+	Variant string
+	URL     string
+}
+
+// Head describes a thumbnail variant without downloading it
+type Head struct {
+	Variant     string
+	URL         string
+	ContentType string
+	// Size of the whole image, -1 if unknown
+	Size int64
+	// Decoded dimensions, nominal ones of the variant if unknown
+	Width  int
+	Height int
+}
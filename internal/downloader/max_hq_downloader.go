@@ -1,28 +1,142 @@
 package downloader
 
 import (
+	"bytes"
 	"context"
+	"expvar"
 	"fmt"
+	"image"
 	"io"
+	"log/slog"
 	"net"
 	"net/http"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/pegov/yt-thumbnails-go/internal/logging"
+)
+
+const urlFormat = "https://i.ytimg.com/vi/%s/%s.jpg"
+
+// Thumbnail variants served by i.ytimg.com
+const (
+	VariantMaxRes  = "maxresdefault"
+	VariantSd      = "sddefault"
+	VariantHq      = "hqdefault"
+	VariantMq      = "mqdefault"
+	VariantDefault = "default"
 )
 
+// Variants from the largest to the smallest
+var Variants = []string{VariantMaxRes, VariantSd, VariantHq, VariantMq, VariantDefault}
+
+// FrameCount is the number of frame captures generated for a video.
+// Frame 0 is a full size one, frames 1-3 are captured along the video.
+const FrameCount = 4
+
+// frameVariants are tried in order for each frame index
+var frameVariants = [FrameCount][]string{
+	{"0"},
+	{"hq1", "1"},
+	{"hq2", "2"},
+	{"hq3", "3"},
+}
+
+// Nominal dimensions of variants, actual images may be letterboxed inside
+var variantSizes = map[string][2]int{
+	VariantMaxRes:  {1280, 720},
+	VariantSd:      {640, 480},
+	VariantHq:      {480, 360},
+	VariantMq:      {320, 180},
+	VariantDefault: {120, 90},
+
+	"0":   {480, 360},
+	"1":   {120, 90},
+	"2":   {120, 90},
+	"3":   {120, 90},
+	"hq1": {480, 360},
+	"hq2": {480, 360},
+	"hq3": {480, 360},
+}
+
+func IsValidVariant(variant string) bool {
+	_, ok := variantSizes[variant]
+	return ok
+}
+
+// Size of the generic gray image i.ytimg.com returns with status 200
+// for missing variants. It is a real size only for VariantDefault and small frames.
 const (
-	urlFormatMaxRes = "https://i.ytimg.com/vi/%s/maxresdefault.jpg"
-	urlFormatHq     = "https://i.ytimg.com/vi/%s/hqdefault.jpg"
+	placeholderWidth  = 120
+	placeholderHeight = 90
 )
 
-type MaxResOrHqDownloader struct{}
+// placeholders counts placeholder responses per variant, exposed via expvar
+var placeholders = expvar.NewMap("downloader_placeholders")
+
+func isPlaceholder(config image.Config) bool {
+	return config.Width == placeholderWidth && config.Height == placeholderHeight
+}
+
+// MaxResOrHqDownloader zero value uses http.DefaultClient
+// and only rejects empty and malformed images
+type MaxResOrHqDownloader struct {
+	client *http.Client
+	// headers added to every upstream request, e.g. User-Agent
+	headers   http.Header
+	validator Validator
+	// maxSize of response body in bytes, 0 - no limit
+	maxSize int64
+}
+
+func NewMaxResOrHqDownloader(
+	client *http.Client,
+	headers http.Header,
+	validator Validator,
+	maxSize int64,
+) MaxResOrHqDownloader {
+	return MaxResOrHqDownloader{
+		client:    client,
+		headers:   headers,
+		validator: validator,
+		maxSize:   maxSize,
+	}
+}
+
+func (d MaxResOrHqDownloader) do(req *http.Request) (*http.Response, error) {
+	for name, values := range d.headers {
+		req.Header[name] = values
+	}
+
+	client := d.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
+
+var tracer = otel.Tracer("github.com/pegov/yt-thumbnails-go/internal/downloader")
+
+func (d MaxResOrHqDownloader) download(ctx context.Context, variant string, url string) (t *Thumbnail, err error) {
+	ctx, span := tracer.Start(ctx, "download "+variant)
+	span.SetAttributes(attribute.String("http.url", url))
+	defer func() {
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
 
-func download(ctx context.Context, url string) ([]byte, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, ErrCouldNotCreateRequest
 	}
 
-	client := http.DefaultClient
-	res, err := client.Do(req)
+	res, err := d.do(req)
 	if res != nil {
 		defer res.Body.Close()
 	}
@@ -33,28 +147,191 @@ func download(ctx context.Context, url string) ([]byte, error) {
 		return nil, ErrCouldNotMakeRequest
 	}
 
+	span.SetAttributes(attribute.Int("http.status_code", res.StatusCode))
 	if res.StatusCode == 404 {
 		return nil, ErrNotFound
 	}
+	if res.StatusCode >= 500 {
+		return nil, ErrServerError
+	}
+
+	body, err := readBody(res.Body, res.ContentLength, d.maxSize)
+	if err != nil {
+		if err == ErrTooLarge {
+			logging.FromContext(ctx, slog.Default()).Warn(
+				"HTTP request: response too large",
+				slog.String("url", url),
+				slog.Int64("content_length", res.ContentLength),
+			)
+		}
+		return nil, err
+	}
 
-	body, err := io.ReadAll(res.Body)
+	// Invalid responses are never returned, so never cached
+	config, err := d.validator.Validate(res.Header.Get("Content-Type"), res.ContentLength, body)
+	if err != nil {
+		span.AddEvent("invalid image")
+		logging.FromContext(ctx, slog.Default()).Warn(
+			"HTTP request: invalid image",
+			slog.String("url", url),
+			slog.Any("err", err),
+		)
+		return nil, err
+	}
+
+	// Small variants have the same size as the placeholder
+	if size := variantSizes[variant]; size != [2]int{placeholderWidth, placeholderHeight} && isPlaceholder(config) {
+		span.AddEvent("placeholder")
+		placeholders.Add(variant, 1)
+		logging.FromContext(ctx, slog.Default()).Info(
+			"HTTP request: placeholder image",
+			slog.String("url", url),
+		)
+		return nil, ErrPlaceholder
+	}
+
+	return &Thumbnail{Data: body, Variant: variant, URL: url}, nil
+}
+
+func (d MaxResOrHqDownloader) head(ctx context.Context, variant string, url string) (h *Head, err error) {
+	ctx, span := tracer.Start(ctx, "head "+variant)
+	span.SetAttributes(attribute.String("http.url", url))
+	defer func() {
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	// HEAD can't tell placeholders served with status 200 from real images,
+	// the beginning of the image is enough to decode its size
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, ErrCouldNotCreateRequest
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", headProbeSize-1))
+
+	res, err := d.do(req)
+	if res != nil {
+		defer res.Body.Close()
+	}
+
+	if e, ok := err.(net.Error); ok && e.Timeout() {
+		return nil, ErrTimeout
+	} else if err != nil {
+		return nil, ErrCouldNotMakeRequest
+	}
+
+	span.SetAttributes(attribute.Int("http.status_code", res.StatusCode))
+	if res.StatusCode >= 500 {
+		return nil, ErrServerError
+	}
+	// Including 403, 416 and redirects that were not followed
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusPartialContent {
+		return nil, ErrNotFound
+	}
+
+	probe, err := io.ReadAll(io.LimitReader(res.Body, headProbeSize))
 	if err != nil {
 		return nil, ErrCouldNotReadBody
 	}
+	size := variantSizes[variant]
+	// Nominal size if the probe is too short to decode
+	if config, _, err := image.DecodeConfig(bytes.NewReader(probe)); err == nil {
+		if size != [2]int{placeholderWidth, placeholderHeight} && isPlaceholder(config) {
+			span.AddEvent("placeholder")
+			placeholders.Add(variant, 1)
+			return nil, ErrPlaceholder
+		}
+		size = [2]int{config.Width, config.Height}
+	}
 
-	return body, nil
+	return &Head{
+		Variant:     variant,
+		URL:         url,
+		ContentType: res.Header.Get("Content-Type"),
+		Size:        totalSize(res),
+		Width:       size[0],
+		Height:      size[1],
+	}, nil
+}
+
+// headProbeSize is enough for JPEG headers of thumbnails without EXIF
+const headProbeSize = 4096
+
+// totalSize of response body, also for partial responses. -1 if unknown.
+func totalSize(res *http.Response) int64 {
+	if res.StatusCode != http.StatusPartialContent {
+		return res.ContentLength
+	}
+	_, total, ok := strings.Cut(res.Header.Get("Content-Range"), "/")
+	if !ok {
+		return -1
+	}
+	size, err := strconv.ParseInt(total, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return size
 }
 
 func (d MaxResOrHqDownloader) DownloadThumbnail(
 	ctx context.Context,
 	videoID string,
-) ([]byte, error) {
-	maxResURL := fmt.Sprintf(urlFormatMaxRes, videoID)
-	b, err := download(ctx, maxResURL)
+) (*Thumbnail, error) {
+	t, err := d.DownloadVariant(ctx, videoID, VariantMaxRes)
 	if err == nil {
-		return b, nil
+		return t, nil
+	}
+
+	return d.DownloadVariant(ctx, videoID, VariantHq)
+}
+
+// DownloadVariant downloads exactly one thumbnail variant without fallback
+func (d MaxResOrHqDownloader) DownloadVariant(
+	ctx context.Context,
+	videoID string,
+	variant string,
+) (*Thumbnail, error) {
+	if !IsValidVariant(variant) {
+		return nil, ErrInvalidVariant
+	}
+
+	url := fmt.Sprintf(urlFormat, videoID, variant)
+	return d.download(ctx, variant, url)
+}
+
+// DownloadFrame downloads frame capture by index with fallback
+// from the high quality one to the small one
+func (d MaxResOrHqDownloader) DownloadFrame(
+	ctx context.Context,
+	videoID string,
+	index int,
+) (t *Thumbnail, err error) {
+	if index < 0 || index >= FrameCount {
+		return nil, ErrInvalidVariant
+	}
+
+	for _, variant := range frameVariants[index] {
+		t, err = d.DownloadVariant(ctx, videoID, variant)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return nil, err
+}
+
+// HeadVariant checks that a variant exists with a ranged GET request,
+// placeholders are reported as ErrPlaceholder
+func (d MaxResOrHqDownloader) HeadVariant(
+	ctx context.Context,
+	videoID string,
+	variant string,
+) (*Head, error) {
+	if !IsValidVariant(variant) {
+		return nil, ErrInvalidVariant
 	}
 
-	urlHq := fmt.Sprintf(urlFormatHq, videoID)
-	return download(ctx, urlHq)
+	url := fmt.Sprintf(urlFormat, videoID, variant)
+	return d.head(ctx, variant, url)
 }
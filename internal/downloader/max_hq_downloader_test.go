@@ -1,9 +1,16 @@
 package downloader
 
 import (
+	"bytes"
 	"context"
+	"expvar"
+	"image"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -22,7 +29,8 @@ func TestMaxOrHqDownloaderMaxRes(t *testing.T) {
 		t.Fatalf("TestMaxOrHqDownloaderMaxRes: http error %v", err)
 	}
 
-	assert.Equal(t, actualMaxRes, wantMaxRes)
+	assert.Equal(t, actualMaxRes.Data, wantMaxRes)
+	assert.Equal(t, VariantMaxRes, actualMaxRes.Variant)
 }
 
 func TestMaxOrHqDownloaderHq(t *testing.T) {
@@ -32,5 +40,223 @@ func TestMaxOrHqDownloaderHq(t *testing.T) {
 		t.Fatalf("TestMaxOrHqDownloaderMaxRes: http error %v", err)
 	}
 
-	assert.Equal(t, actualHq, wantHq)
+	assert.Equal(t, actualHq.Data, wantHq)
+	assert.Equal(t, VariantHq, actualHq.Variant)
+	assert.Equal(t, "https://i.ytimg.com/vi/jNQXAC9IVRw/hqdefault.jpg", actualHq.URL)
+}
+
+func TestDownloadVariantInvalid(t *testing.T) {
+	_, err := d.DownloadVariant(context.Background(), videoIDHq, "hq720")
+	assert.ErrorIs(t, err, ErrInvalidVariant)
+}
+
+func TestDownloadFrameInvalid(t *testing.T) {
+	_, err := d.DownloadFrame(context.Background(), videoIDHq, FrameCount)
+	assert.ErrorIs(t, err, ErrInvalidVariant)
+	_, err = d.DownloadFrame(context.Background(), videoIDHq, -1)
+	assert.ErrorIs(t, err, ErrInvalidVariant)
+	assert.True(t, IsValidVariant("hq3"))
+}
+
+func TestHeadVariant(t *testing.T) {
+	// Letterboxed thumbnail is smaller than nominal 480x360
+	jpg := encodeJPEG(480, 270)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+			return
+		case "/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+			return
+		case "/short":
+			w.Write(jpg[:2])
+			return
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		http.ServeContent(w, r, "hqdefault.jpg", time.Time{}, bytes.NewReader(jpg))
+	}))
+	defer srv.Close()
+
+	h, err := d.head(context.Background(), VariantHq, srv.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, "image/jpeg", h.ContentType)
+	assert.Equal(t, 480, h.Width)
+	assert.Equal(t, 270, h.Height)
+	assert.Equal(t, int64(len(jpg)), h.Size)
+
+	// Nominal size if the probe can't be decoded
+	h, err = d.head(context.Background(), VariantHq, srv.URL+"/short")
+	assert.Nil(t, err)
+	assert.Equal(t, 360, h.Height)
+
+	_, err = d.head(context.Background(), VariantMaxRes, srv.URL+"/missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = d.head(context.Background(), VariantMaxRes, srv.URL+"/forbidden")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func placeholderCount(variant string) int64 {
+	if v, ok := placeholders.Get(variant).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+func TestHeadPlaceholder(t *testing.T) {
+	placeholder := encodeJPEG(120, 90)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "placeholder.jpg", time.Time{}, bytes.NewReader(placeholder))
+	}))
+	defer srv.Close()
+
+	before := placeholderCount(VariantMaxRes)
+	_, err := d.head(context.Background(), VariantMaxRes, srv.URL)
+	assert.ErrorIs(t, err, ErrPlaceholder)
+	assert.Equal(t, before+1, placeholderCount(VariantMaxRes))
+
+	h, err := d.head(context.Background(), VariantDefault, srv.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(placeholder)), h.Size)
+}
+
+func TestHeadVariantInvalid(t *testing.T) {
+	_, err := d.HeadVariant(context.Background(), videoIDHq, "hq720")
+	assert.ErrorIs(t, err, ErrInvalidVariant)
+}
+
+func encodeJPEG(width int, height int) []byte {
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height)), nil)
+	return buf.Bytes()
+}
+
+func TestDownloadPlaceholder(t *testing.T) {
+	placeholder := encodeJPEG(120, 90)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(placeholder)
+	}))
+	defer srv.Close()
+
+	before := placeholderCount(VariantMaxRes)
+	_, err := d.download(context.Background(), VariantMaxRes, srv.URL)
+	assert.ErrorIs(t, err, ErrPlaceholder)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, before+1, placeholderCount(VariantMaxRes))
+
+	// default variant really is 120x90
+	th, err := d.download(context.Background(), VariantDefault, srv.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, placeholder, th.Data)
+
+	// as are small frames, but not high quality ones
+	_, err = d.download(context.Background(), "1", srv.URL)
+	assert.Nil(t, err)
+	_, err = d.download(context.Background(), "hq1", srv.URL)
+	assert.ErrorIs(t, err, ErrPlaceholder)
+}
+
+func TestValidator(t *testing.T) {
+	v := Validator{MinSize: 100}
+	jpg := encodeJPEG(480, 360)
+
+	config, err := v.Validate("image/jpeg", int64(len(jpg)), jpg)
+	assert.Nil(t, err)
+	assert.Equal(t, 480, config.Width)
+
+	_, err = v.Validate("", -1, jpg)
+	assert.Nil(t, err)
+
+	for _, c := range []struct {
+		contentType   string
+		contentLength int64
+		body          []byte
+	}{
+		{"image/jpeg", int64(len(jpg)) + 10, jpg},
+		{"image/jpeg", -1, jpg[:len(jpg)/2]},
+		{"image/jpeg", 0, []byte{}},
+		{"image/jpeg", -1, jpg[:50]},
+		{"text/html; charset=utf-8", -1, jpg},
+		{"", -1, bytes.Repeat([]byte("<html></html>"), 20)},
+	} {
+		_, err := v.Validate(c.contentType, c.contentLength, c.body)
+		assert.ErrorIs(t, err, ErrInvalidImage)
+	}
+}
+
+func TestReadBody(t *testing.T) {
+	body := bytes.Repeat([]byte("x"), 1000)
+
+	b, err := readBody(bytes.NewReader(body), int64(len(body)), 1000)
+	assert.Nil(t, err)
+	assert.Equal(t, body, b)
+
+	b, err = readBody(bytes.NewReader(body), -1, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, body, b)
+
+	// Content-Length is checked before reading, body while reading
+	_, err = readBody(bytes.NewReader(body), int64(len(body)), 999)
+	assert.ErrorIs(t, err, ErrTooLarge)
+	_, err = readBody(bytes.NewReader(body), -1, 999)
+	assert.ErrorIs(t, err, ErrTooLarge)
+}
+
+func TestDownloadTooLarge(t *testing.T) {
+	jpg := encodeJPEG(480, 360)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(jpg)
+	}))
+	defer srv.Close()
+
+	d := NewMaxResOrHqDownloader(nil, nil, Validator{}, int64(len(jpg))-1)
+	_, err := d.download(context.Background(), VariantHq, srv.URL)
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	d = NewMaxResOrHqDownloader(nil, nil, Validator{}, int64(len(jpg)))
+	th, err := d.download(context.Background(), VariantHq, srv.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, jpg, th.Data)
+}
+
+func BenchmarkReadBody(b *testing.B) {
+	body := bytes.Repeat([]byte("x"), 100<<10)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			readBody(bytes.NewReader(body), int64(len(body)), 5<<20)
+		}
+	})
+}
+
+func TestDownloadClientAndHeaders(t *testing.T) {
+	jpg := encodeJPEG(480, 360)
+	var userAgent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		w.Write(jpg)
+	}))
+	defer srv.Close()
+
+	client, err := NewHTTPClient(ClientConfig{
+		DialTimeout:         time.Second,
+		MaxIdleConnsPerHost: 16,
+		ProxyURL:            ProxyDirect,
+	})
+	assert.Nil(t, err)
+	headers, err := ParseHeaders([]string{"User-Agent: yt-thumbnails/1.0"})
+	assert.Nil(t, err)
+
+	d := NewMaxResOrHqDownloader(client, headers, Validator{}, 0)
+	_, err = d.download(context.Background(), VariantHq, srv.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, "yt-thumbnails/1.0", userAgent)
+}
+
+func TestNewHTTPClientInvalid(t *testing.T) {
+	_, err := NewHTTPClient(ClientConfig{ProxyURL: "localhost"})
+	assert.ErrorIs(t, err, ErrInvalidProxyURL)
+
+	_, err = ParseHeaders([]string{"User-Agent"})
+	assert.ErrorIs(t, err, ErrInvalidHeader)
 }
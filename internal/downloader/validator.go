@@ -0,0 +1,67 @@
+package downloader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	"mime"
+	"strings"
+
+	_ "golang.org/x/image/webp"
+)
+
+// Validator rejects responses that are not complete thumbnails:
+// truncated transfers, HTML pages from proxies, empty bodies.
+type Validator struct {
+	// MinSize of the body in bytes, maximum is enforced while reading it
+	MinSize int64
+}
+
+// Validate checks body against response headers and decodes image header.
+// Content length is -1 if unknown.
+func (v Validator) Validate(contentType string, contentLength int64, body []byte) (image.Config, error) {
+	size := int64(len(body))
+	if contentLength >= 0 && contentLength != size {
+		return image.Config{}, fmt.Errorf("%w: got %d of %d bytes", ErrInvalidImage, size, contentLength)
+	}
+	if size == 0 || size < v.MinSize {
+		return image.Config{}, fmt.Errorf("%w: too small (%d bytes)", ErrInvalidImage, size)
+	}
+
+	if contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || !strings.HasPrefix(mediaType, "image/") {
+			return image.Config{}, fmt.Errorf("%w: content type %q", ErrInvalidImage, contentType)
+		}
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(body))
+	if err != nil {
+		return image.Config{}, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if format != "jpeg" && format != "webp" {
+		return image.Config{}, fmt.Errorf("%w: unexpected format %s", ErrInvalidImage, format)
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return image.Config{}, fmt.Errorf("%w: empty image", ErrInvalidImage)
+	}
+	if !isComplete(format, body) {
+		return image.Config{}, fmt.Errorf("%w: truncated %s", ErrInvalidImage, format)
+	}
+	return config, nil
+}
+
+// isComplete catches truncated bodies without Content-Length:
+// JPEG must end with EOI marker, WebP must match RIFF chunk size.
+func isComplete(format string, body []byte) bool {
+	switch format {
+	case "jpeg":
+		return bytes.HasSuffix(body, []byte{0xff, 0xd9})
+	case "webp":
+		return len(body) >= 8 && int(binary.LittleEndian.Uint32(body[4:8]))+8 == len(body)
+	default:
+		return true
+	}
+}
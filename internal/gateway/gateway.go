@@ -0,0 +1,266 @@
+package gateway
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/pegov/yt-thumbnails-go/api/thumbnail_v1"
+)
+
+type Getter interface {
+	Get(ctx context.Context, req *pb.GetRequest) (*pb.GetResponse, error)
+}
+
+// Handler serves raw thumbnail images over plain HTTP
+// through the same pipeline as ThumbnailService.Get.
+//
+//	GET /vi/{videoID}/{variant}.jpg
+//	GET /thumbnail?url=...
+//
+// Both accept width, height, fit (contain, cover, fill), crop_letterbox,
+// format (jpeg, png), quality, progressive and strip_metadata query parameters.
+type Handler struct {
+	logger *slog.Logger
+	getter Getter
+	// maxAge for Cache-Control (time.Duration)
+	maxAge atomic.Int64
+	mux    *http.ServeMux
+}
+
+func NewHandler(logger *slog.Logger, getter Getter, maxAge time.Duration) *Handler {
+	h := &Handler{
+		logger: logger,
+		getter: getter,
+		mux:    http.NewServeMux(),
+	}
+	h.maxAge.Store(int64(maxAge))
+	h.mux.HandleFunc("/vi/", h.handleVariant)
+	h.mux.HandleFunc("/thumbnail", h.handleURL)
+	return h
+}
+
+// SetMaxAge changes Cache-Control max-age, e.g. after cache TTL is reloaded
+func (h *Handler) SetMaxAge(maxAge time.Duration) {
+	h.maxAge.Store(int64(maxAge))
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) handleVariant(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/vi/"), "/")
+	if len(parts) != 2 || !strings.HasSuffix(parts[1], ".jpg") {
+		http.NotFound(w, r)
+		return
+	}
+
+	videoID := parts[0]
+	variant := strings.TrimSuffix(parts[1], ".jpg")
+	h.serve(w, r, &pb.GetRequest{Url: videoID, Variant: variant})
+}
+
+func (h *Handler) handleURL(w http.ResponseWriter, r *http.Request) {
+	url := r.URL.Query().Get("url")
+	if url == "" {
+		http.Error(w, "url: required", http.StatusBadRequest)
+		return
+	}
+
+	h.serve(w, r, &pb.GetRequest{Url: url})
+}
+
+func (h *Handler) serve(w http.ResponseWriter, r *http.Request, req *pb.GetRequest) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := applyTransform(req, r.URL.Query()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	applyCacheControl(req, r.Header.Get("Cache-Control"))
+	res, err := h.getter.Get(r.Context(), req)
+	if err != nil {
+		st := status.Convert(err)
+		code := HTTPStatusFromCode(st.Code())
+		if req.OnlyIfCached && st.Code() == codes.NotFound {
+			// RFC 9111: only-if-cached miss is 504
+			code = http.StatusGatewayTimeout
+		}
+		http.Error(w, st.Message(), code)
+		return
+	}
+
+	b := res.GetData()
+	hash := res.GetSha256()
+	if hash == "" {
+		sum := sha256.Sum256(b)
+		hash = hex.EncodeToString(sum[:])
+	}
+	etag := `"` + hash + `"`
+
+	header := w.Header()
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", h.maxAge.Load()/int64(time.Second)))
+	header.Set("Age", fmt.Sprint(res.GetAge()))
+
+	if matchETag(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	contentType := res.GetContentType()
+	if contentType == "" {
+		contentType = http.DetectContentType(b)
+	}
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Length", fmt.Sprint(len(b)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		if _, err := w.Write(b); err != nil {
+			h.logger.Warn("HTTP gateway: write error", slog.Any("err", err))
+		}
+	}
+}
+
+// applyCacheControl maps request Cache-Control directives
+// (no-cache, only-if-cached, max-age) to GetRequest options
+func applyCacheControl(req *pb.GetRequest, cacheControl string) {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-cache":
+			req.NoCache = true
+		case "only-if-cached":
+			req.OnlyIfCached = true
+		case "max-age":
+			if maxAge, err := strconv.ParseInt(value, 10, 64); err == nil && maxAge >= 0 {
+				req.MaxAge = maxAge
+			}
+		}
+	}
+	// no-cache wins, Get rejects the combination
+	if req.NoCache {
+		req.OnlyIfCached = false
+	}
+}
+
+// applyTransform maps width, height, fit, crop_letterbox, format,
+// quality, progressive and strip_metadata query parameters to GetRequest
+func applyTransform(req *pb.GetRequest, query url.Values) error {
+	format := &pb.Format{}
+	ints := map[string]*int32{"width": &req.Width, "height": &req.Height, "quality": &format.Quality}
+	for name, field := range ints {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return fmt.Errorf("%s: invalid number", name)
+		}
+		*field = int32(n)
+	}
+
+	bools := map[string]*bool{"progressive": &format.Progressive, "strip_metadata": &format.StripMetadata}
+	for name, field := range bools {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s: invalid bool", name)
+		}
+		*field = b
+	}
+
+	if fit := query.Get("fit"); fit != "" {
+		value, ok := pb.Fit_value["FIT_"+strings.ToUpper(fit)]
+		if !ok {
+			return fmt.Errorf("fit: invalid fit")
+		}
+		req.Fit = pb.Fit(value)
+	}
+
+	if encoding := query.Get("format"); encoding != "" {
+		value, ok := pb.Encoding_value["ENCODING_"+strings.ToUpper(encoding)]
+		if !ok {
+			return fmt.Errorf("format: invalid format")
+		}
+		format.Encoding = pb.Encoding(value)
+	}
+	if proto.Size(format) > 0 {
+		req.Format = format
+	}
+
+	if crop := query.Get("crop_letterbox"); crop != "" {
+		value, err := strconv.ParseBool(crop)
+		if err != nil {
+			return fmt.Errorf("crop_letterbox: invalid bool")
+		}
+		req.CropLetterbox = &value
+	}
+	return nil
+}
+
+func matchETag(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		candidate = strings.TrimPrefix(candidate, "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// HTTPStatusFromCode maps gRPC status codes to HTTP statuses
+func HTTPStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument, codes.OutOfRange, codes.FailedPrecondition:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Canceled:
+		// Client closed request (nginx convention)
+		return 499
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
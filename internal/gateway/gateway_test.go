@@ -0,0 +1,152 @@
+package gateway
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pegov/yt-thumbnails-go/api/thumbnail_v1"
+)
+
+var wantBytes, _ = os.ReadFile("../../testdata/hq.jpg")
+
+type fakeGetter struct {
+	req *pb.GetRequest
+}
+
+func (g *fakeGetter) Get(ctx context.Context, req *pb.GetRequest) (*pb.GetResponse, error) {
+	g.req = req
+	if req.Url == "dQw4wXXXXXX" {
+		return nil, status.Error(codes.NotFound, "not found")
+	}
+	return &pb.GetResponse{Url: req.Url, VideoId: "jNQXAC9IVRw", Data: wantBytes}, nil
+}
+
+func newHandler() (*Handler, *fakeGetter) {
+	g := &fakeGetter{}
+	return NewHandler(slog.Default(), g, 24*time.Hour), g
+}
+
+func TestVariant(t *testing.T) {
+	h, g := newHandler()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/vi/jNQXAC9IVRw/hqdefault.jpg", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "jNQXAC9IVRw", g.req.Url)
+	assert.Equal(t, "hqdefault", g.req.Variant)
+	assert.Equal(t, "image/jpeg", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "public, max-age=86400", rec.Header().Get("Cache-Control"))
+	assert.NotEmpty(t, rec.Header().Get("ETag"))
+	assert.Equal(t, wantBytes, rec.Body.Bytes())
+}
+
+func TestURL(t *testing.T) {
+	h, g := newHandler()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/thumbnail?url=https%3A%2F%2Fyoutu.be%2FjNQXAC9IVRw", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "https://youtu.be/jNQXAC9IVRw", g.req.Url)
+	assert.Equal(t, "", g.req.Variant)
+}
+
+func TestNotModified(t *testing.T) {
+	h, _ := newHandler()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/vi/jNQXAC9IVRw/hqdefault.jpg", nil))
+	etag := rec.Header().Get("ETag")
+
+	req := httptest.NewRequest("GET", "/vi/jNQXAC9IVRw/hqdefault.jpg", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Equal(t, 0, rec.Body.Len())
+}
+
+func TestErrors(t *testing.T) {
+	h, _ := newHandler()
+	for path, code := range map[string]int{
+		"/vi/dQw4wXXXXXX/hqdefault.jpg": http.StatusNotFound,
+		"/vi/jNQXAC9IVRw/hqdefault.png": http.StatusNotFound,
+		"/thumbnail":                    http.StatusBadRequest,
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, code, rec.Code, path)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/vi/jNQXAC9IVRw/hqdefault.jpg", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestCacheControl(t *testing.T) {
+	h, g := newHandler()
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/vi/jNQXAC9IVRw/hqdefault.jpg", nil)
+	r.Header.Set("Cache-Control", "no-cache, max-age=60")
+	h.ServeHTTP(rec, r)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, g.req.NoCache)
+	assert.Equal(t, int64(60), g.req.MaxAge)
+	assert.Equal(t, "0", rec.Header().Get("Age"))
+
+	rec = httptest.NewRecorder()
+	r = httptest.NewRequest("GET", "/vi/dQw4wXXXXXX/hqdefault.jpg", nil)
+	r.Header.Set("Cache-Control", "only-if-cached")
+	h.ServeHTTP(rec, r)
+	assert.True(t, g.req.OnlyIfCached)
+	assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+}
+
+func TestTransform(t *testing.T) {
+	h, g := newHandler()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/vi/jNQXAC9IVRw/hqdefault.jpg?width=320&fit=cover", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, int32(320), g.req.Width)
+	assert.Equal(t, pb.Fit_FIT_COVER, g.req.Fit)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/thumbnail?url=jNQXAC9IVRw&height=abc", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/thumbnail?url=jNQXAC9IVRw&fit=zoom", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/thumbnail?url=jNQXAC9IVRw&crop_letterbox=false", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.False(t, *g.req.CropLetterbox)
+	assert.Equal(t, int32(0), g.req.Width)
+	assert.Nil(t, g.req.Format)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/thumbnail?url=jNQXAC9IVRw&format=png&strip_metadata=1", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, pb.Encoding_ENCODING_PNG, g.req.Format.Encoding)
+	assert.True(t, g.req.Format.StripMetadata)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/thumbnail?url=jNQXAC9IVRw&quality=high", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestHTTPStatusFromCode(t *testing.T) {
+	assert.Equal(t, http.StatusBadRequest, HTTPStatusFromCode(codes.InvalidArgument))
+	assert.Equal(t, http.StatusServiceUnavailable, HTTPStatusFromCode(codes.Unavailable))
+	assert.Equal(t, http.StatusGatewayTimeout, HTTPStatusFromCode(codes.DeadlineExceeded))
+	assert.Equal(t, http.StatusInternalServerError, HTTPStatusFromCode(codes.Internal))
+}
@@ -0,0 +1,195 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+)
+
+const (
+	grpcContentType        = "application/grpc"
+	grpcWebContentType     = "application/grpc-web"
+	grpcWebTextContentType = "application/grpc-web-text"
+	// http2.TrailerPrefix, used by grpc for trailers not declared in advance
+	trailerPrefix = "Trailer:"
+)
+
+// NewGRPCWebHandler serves gRPC-Web requests (and CORS preflights of
+// registered methods) with grpcServer and passes everything else to next.
+// Origin "*" allows any origin.
+//
+// gRPC-Web is translated to gRPC in process, as described in
+// https://github.com/grpc/grpc/blob/master/doc/PROTOCOL-WEB.md,
+// binary and base64 text modes are supported, websockets are not.
+func NewGRPCWebHandler(
+	grpcServer *grpc.Server,
+	allowedOrigins []string,
+	next http.Handler,
+) http.Handler {
+	origins := make(map[string]struct{}, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		origins[origin] = struct{}{}
+	}
+	isAllowed := func(origin string) bool {
+		if _, ok := origins["*"]; ok {
+			return true
+		}
+		_, ok := origins[origin]
+		return ok
+	}
+
+	methods := make(map[string]struct{})
+	for service, info := range grpcServer.GetServiceInfo() {
+		for _, method := range info.Methods {
+			methods["/"+service+"/"+method.Name] = struct{}{}
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		isWeb := r.Method == http.MethodPost &&
+			strings.HasPrefix(r.Header.Get("Content-Type"), grpcWebContentType)
+		_, registered := methods[r.URL.Path]
+		isPreflight := r.Method == http.MethodOptions && registered &&
+			strings.Contains(strings.ToLower(r.Header.Get("Access-Control-Request-Headers")), "x-grpc-web")
+		if !isWeb && !isPreflight {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		origin := r.Header.Get("Origin")
+		h := w.Header()
+		h.Add("Vary", "Origin")
+		if origin != "" && isAllowed(origin) {
+			h.Set("Access-Control-Allow-Origin", origin)
+			h.Set("Access-Control-Expose-Headers", "grpc-status, grpc-message")
+			if isPreflight {
+				h.Set("Access-Control-Allow-Methods", http.MethodPost)
+				h.Set("Access-Control-Allow-Headers", r.Header.Get("Access-Control-Request-Headers"))
+			}
+		}
+		if isPreflight {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		serveGRPCWeb(grpcServer, w, r)
+	})
+}
+
+// serveGRPCWeb passes gRPC-Web request to grpcServer as a gRPC one
+func serveGRPCWeb(grpcServer *grpc.Server, w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get("Content-Type")
+	webContentType := grpcWebContentType
+	req := r.Clone(r.Context())
+	if strings.HasPrefix(contentType, grpcWebTextContentType) {
+		webContentType = grpcWebTextContentType
+		req.Body = struct {
+			io.Reader
+			io.Closer
+		}{base64.NewDecoder(base64.StdEncoding, r.Body), r.Body}
+	}
+	// grpc serves http.Handler requests only as HTTP/2 ones
+	req.ProtoMajor, req.ProtoMinor = 2, 0
+	req.Header.Set("Content-Type", strings.Replace(contentType, webContentType, grpcContentType, 1))
+	req.Header.Del("Content-Length")
+	req.ContentLength = -1
+
+	rw := newWebResponseWriter(w, webContentType)
+	grpcServer.ServeHTTP(rw, req)
+	rw.finish()
+}
+
+// webResponseWriter turns gRPC response into gRPC-Web one, trailers are
+// sent as the last frame of the body
+type webResponseWriter struct {
+	w           http.ResponseWriter
+	header      http.Header
+	contentType string
+	// sent are headers written before the body, the rest are trailers
+	sent    map[string]struct{}
+	body    io.Writer
+	encoder io.WriteCloser
+}
+
+func newWebResponseWriter(w http.ResponseWriter, contentType string) *webResponseWriter {
+	rw := &webResponseWriter{w: w, header: make(http.Header), contentType: contentType, body: w}
+	if contentType == grpcWebTextContentType {
+		rw.encoder = base64.NewEncoder(base64.StdEncoding, w)
+		rw.body = rw.encoder
+	}
+	return rw
+}
+
+func (rw *webResponseWriter) Header() http.Header {
+	return rw.header
+}
+
+func (rw *webResponseWriter) WriteHeader(code int) {
+	if rw.sent != nil {
+		return
+	}
+	rw.sent = make(map[string]struct{}, len(rw.header))
+	h := rw.w.Header()
+	for k, vv := range rw.header {
+		if k == "Trailer" {
+			continue
+		}
+		rw.sent[k] = struct{}{}
+		if k == "Content-Type" {
+			vv = []string{strings.Replace(vv[0], grpcContentType, rw.contentType, 1)}
+		}
+		// Trailers-only response has metadata trailers as well
+		h[strings.TrimPrefix(k, trailerPrefix)] = vv
+	}
+	rw.w.WriteHeader(code)
+}
+
+func (rw *webResponseWriter) Write(b []byte) (int, error) {
+	rw.WriteHeader(http.StatusOK)
+	return rw.body.Write(b)
+}
+
+// Flush sends what is written so far, base64 allows padding in between
+func (rw *webResponseWriter) Flush() {
+	if rw.sent == nil {
+		return
+	}
+	if rw.encoder != nil {
+		rw.encoder.Close()
+		rw.encoder = base64.NewEncoder(base64.StdEncoding, rw.w)
+		rw.body = rw.encoder
+	}
+	if f, ok := rw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// finish writes trailers. Without body they all go to headers.
+func (rw *webResponseWriter) finish() {
+	if rw.sent == nil {
+		rw.WriteHeader(http.StatusOK)
+		rw.Flush()
+		return
+	}
+
+	trailers := make(http.Header)
+	for k, vv := range rw.header {
+		if _, ok := rw.sent[k]; ok || k == "Trailer" {
+			continue
+		}
+		trailers[strings.ToLower(strings.TrimPrefix(k, trailerPrefix))] = vv
+	}
+	var buf bytes.Buffer
+	trailers.Write(&buf)
+
+	frame := []byte{1 << 7, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(frame[1:], uint32(buf.Len()))
+	rw.body.Write(frame)
+	rw.body.Write(buf.Bytes())
+	rw.Flush()
+}
@@ -0,0 +1,143 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/pegov/yt-thumbnails-go/api/thumbnail_v1"
+)
+
+type fakeService struct {
+	pb.UnimplementedThumbnailServiceServer
+	getter fakeGetter
+}
+
+func (s *fakeService) Get(ctx context.Context, req *pb.GetRequest) (*pb.GetResponse, error) {
+	return s.getter.Get(ctx, req)
+}
+
+func newGRPCWebHandler() http.Handler {
+	grpcServer := grpc.NewServer()
+	pb.RegisterThumbnailServiceServer(grpcServer, &fakeService{})
+	h, _ := newHandler()
+	return NewGRPCWebHandler(grpcServer, []string{"https://dashboard.example.com"}, h)
+}
+
+// getFrame is a length-prefixed Get request
+func getFrame(url string) []byte {
+	msg, _ := proto.Marshal(&pb.GetRequest{Url: url})
+	var body bytes.Buffer
+	body.WriteByte(0)
+	binary.Write(&body, binary.BigEndian, uint32(len(msg)))
+	body.Write(msg)
+	return body.Bytes()
+}
+
+func TestGRPCWeb(t *testing.T) {
+	h := newGRPCWebHandler()
+
+	req := httptest.NewRequest("POST", "/ThumbnailService/Get", bytes.NewReader(getFrame("jNQXAC9IVRw")))
+	req.Header.Set("Content-Type", "application/grpc-web+proto")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/grpc-web+proto", rec.Header().Get("Content-Type"))
+
+	// First frame is the length-prefixed response message
+	frame := rec.Body.Bytes()
+	assert.Equal(t, byte(0), frame[0])
+	size := binary.BigEndian.Uint32(frame[1:5])
+	var res pb.GetResponse
+	assert.Nil(t, proto.Unmarshal(frame[5:5+size], &res))
+	assert.Equal(t, wantBytes, res.GetData())
+
+	// Then trailers
+	frame = frame[5+size:]
+	assert.Equal(t, byte(0x80), frame[0])
+	assert.Contains(t, string(frame[5:]), "grpc-status: 0\r\n")
+}
+
+func TestGRPCWebText(t *testing.T) {
+	h := newGRPCWebHandler()
+
+	body := base64.StdEncoding.EncodeToString(getFrame("jNQXAC9IVRw"))
+	req := httptest.NewRequest("POST", "/ThumbnailService/Get", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/grpc-web-text")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/grpc-web-text", rec.Header().Get("Content-Type"))
+
+	// Padded base64 chunks, one per flush, so each quantum is decoded alone
+	var frames []byte
+	text := rec.Body.String()
+	for i := 0; i+4 <= len(text); i += 4 {
+		b, err := base64.StdEncoding.DecodeString(text[i : i+4])
+		assert.Nil(t, err)
+		frames = append(frames, b...)
+	}
+	size := binary.BigEndian.Uint32(frames[1:5])
+	var res pb.GetResponse
+	assert.Nil(t, proto.Unmarshal(frames[5:5+size], &res))
+	assert.Equal(t, wantBytes, res.GetData())
+}
+
+func TestGRPCWebError(t *testing.T) {
+	h := newGRPCWebHandler()
+
+	req := httptest.NewRequest("POST", "/ThumbnailService/Get", bytes.NewReader(getFrame("dQw4wXXXXXX")))
+	req.Header.Set("Content-Type", "application/grpc-web")
+	req.Header.Set("Origin", "https://dashboard.example.com")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	// Trailers-only response
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "5", rec.Header().Get("Grpc-Status"))
+	assert.Equal(t, "not found", rec.Header().Get("Grpc-Message"))
+	assert.Equal(t, "https://dashboard.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, rec.Header().Get("Access-Control-Expose-Headers"), "grpc-status")
+	assert.Empty(t, rec.Body.Bytes())
+}
+
+func TestGRPCWebCORS(t *testing.T) {
+	h := newGRPCWebHandler()
+
+	for origin, allowed := range map[string]bool{
+		"https://dashboard.example.com": true,
+		"https://evil.example.com":      false,
+	} {
+		req := httptest.NewRequest("OPTIONS", "/ThumbnailService/Get", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		req.Header.Set("Access-Control-Request-Headers", "content-type,x-grpc-web")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if allowed {
+			assert.Equal(t, origin, rec.Header().Get("Access-Control-Allow-Origin"))
+		} else {
+			assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+		}
+	}
+}
+
+func TestGRPCWebPassThrough(t *testing.T) {
+	h := newGRPCWebHandler()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/vi/jNQXAC9IVRw/hqdefault.jpg", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, wantBytes, rec.Body.Bytes())
+}
@@ -0,0 +1,118 @@
+package health
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+type Circuit interface {
+	IsOpen() bool
+}
+
+// Checker periodically checks dependencies and reports the result
+// to the standard grpc.health.v1.Health service.
+// Nil cache or circuit disables the corresponding check.
+type Checker struct {
+	logger        *slog.Logger
+	srv           *health.Server
+	services      []string
+	cache         Pinger
+	circuit       Circuit
+	checkDraining bool
+	interval      time.Duration
+
+	// mu orders status updates of Check and Drain
+	mu       sync.Mutex
+	draining bool
+}
+
+func NewChecker(
+	logger *slog.Logger,
+	srv *health.Server,
+	services []string,
+	cache Pinger,
+	circuit Circuit,
+	checkDraining bool,
+	interval time.Duration,
+) *Checker {
+	return &Checker{
+		logger:        logger,
+		srv:           srv,
+		services:      services,
+		cache:         cache,
+		circuit:       circuit,
+		checkDraining: checkDraining,
+		interval:      interval,
+	}
+}
+
+// Run checks dependencies every interval until ctx is done
+func (c *Checker) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.Check(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.Check(ctx)
+		}
+	}
+}
+
+// Drain marks server as not serving during graceful shutdown
+func (c *Checker) Drain() {
+	if !c.checkDraining {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.draining = true
+	c.setStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+}
+
+func (c *Checker) Check(ctx context.Context) {
+	status := healthpb.HealthCheckResponse_SERVING
+
+	if c.cache != nil {
+		ctx, cancel := context.WithTimeout(ctx, c.interval)
+		err := c.cache.Ping(ctx)
+		cancel()
+		if err != nil {
+			c.logger.Warn("Health: cache is unreachable", slog.Any("err", err))
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+	}
+
+	if c.circuit != nil && c.circuit.IsOpen() {
+		c.logger.Warn("Health: circuit to upstream is open")
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Drain may have been called while dependencies were checked
+	if c.draining {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	c.setStatus(status)
+}
+
+func (c *Checker) setStatus(status healthpb.HealthCheckResponse_ServingStatus) {
+	// Empty service name is the overall server health
+	c.srv.SetServingStatus("", status)
+	for _, service := range c.services {
+		c.srv.SetServingStatus(service, status)
+	}
+}
@@ -0,0 +1,91 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+var ctx = context.Background()
+
+const service = "ThumbnailService"
+
+type fakePinger struct {
+	err error
+	// called during Ping if set
+	during func()
+}
+
+func (p *fakePinger) Ping(ctx context.Context) error {
+	if p.during != nil {
+		p.during()
+	}
+	return p.err
+}
+
+type fakeCircuit struct {
+	open bool
+}
+
+func (c *fakeCircuit) IsOpen() bool {
+	return c.open
+}
+
+func status(t *testing.T, srv *health.Server) healthpb.HealthCheckResponse_ServingStatus {
+	res, err := srv.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+	assert.Nil(t, err)
+	return res.GetStatus()
+}
+
+func TestChecker(t *testing.T) {
+	srv := health.NewServer()
+	pinger := &fakePinger{}
+	circuit := &fakeCircuit{}
+	checker := NewChecker(slog.Default(), srv, []string{service}, pinger, circuit, true, time.Second)
+
+	checker.Check(ctx)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, status(t, srv))
+
+	pinger.err = errors.New("db is closed")
+	checker.Check(ctx)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status(t, srv))
+
+	pinger.err = nil
+	circuit.open = true
+	checker.Check(ctx)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status(t, srv))
+
+	circuit.open = false
+	checker.Check(ctx)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, status(t, srv))
+
+	checker.Drain()
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status(t, srv))
+	checker.Check(ctx)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status(t, srv))
+}
+
+func TestCheckerDrainDuringCheck(t *testing.T) {
+	srv := health.NewServer()
+	pinger := &fakePinger{}
+	checker := NewChecker(slog.Default(), srv, []string{service}, pinger, nil, true, time.Second)
+	pinger.during = checker.Drain
+
+	checker.Check(ctx)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status(t, srv))
+}
+
+func TestCheckerDisabledChecks(t *testing.T) {
+	srv := health.NewServer()
+	checker := NewChecker(slog.Default(), srv, []string{service}, nil, nil, false, time.Second)
+
+	checker.Drain()
+	checker.Check(ctx)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, status(t, srv))
+}
@@ -0,0 +1,149 @@
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+)
+
+// BlurHash components along x and y, 4x3 suits 16:9 and 4:3 thumbnails
+const (
+	blurHashX = 4
+	blurHashY = 3
+)
+
+// Images are downscaled before hashing, details are lost anyway
+const placeholderSize = 64
+
+func downscale(img image.Image) image.Image {
+	if img.Bounds().Dx() <= placeholderSize {
+		return img
+	}
+	return Resize(img, placeholderSize, 0, FitContain)
+}
+
+const base83 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// BlurHash encodes image as https://blurha.sh placeholder string
+func BlurHash(img image.Image) string {
+	img = downscale(img)
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w == 0 || h == 0 {
+		return ""
+	}
+
+	linear := make([][3]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, bl, _ := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			linear[y*w+x] = [3]float64{srgbToLinear(r >> 8), srgbToLinear(g >> 8), srgbToLinear(bl >> 8)}
+		}
+	}
+
+	var factors [blurHashX * blurHashY][3]float64
+	for j := 0; j < blurHashY; j++ {
+		for i := 0; i < blurHashX; i++ {
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1
+			}
+			var f [3]float64
+			for y := 0; y < h; y++ {
+				by := math.Cos(math.Pi * float64(j*y) / float64(h))
+				for x := 0; x < w; x++ {
+					basis := by * math.Cos(math.Pi*float64(i*x)/float64(w))
+					for c := range f {
+						f[c] += basis * linear[y*w+x][c]
+					}
+				}
+			}
+			for c := range f {
+				f[c] *= normalisation / float64(w*h)
+			}
+			factors[j*blurHashX+i] = f
+		}
+	}
+
+	var sb strings.Builder
+	writeBase83(&sb, (blurHashX-1)+(blurHashY-1)*9, 1)
+
+	maxAC := 0.0
+	for _, f := range factors[1:] {
+		maxAC = max(maxAC, math.Abs(f[0]), math.Abs(f[1]), math.Abs(f[2]))
+	}
+	quantisedMax := int(math.Max(0, math.Min(82, math.Floor(maxAC*166-0.5))))
+	maxValue := float64(quantisedMax+1) / 166
+	writeBase83(&sb, quantisedMax, 1)
+
+	dc := factors[0]
+	writeBase83(&sb, linearToSRGB(dc[0])<<16|linearToSRGB(dc[1])<<8|linearToSRGB(dc[2]), 4)
+	for _, f := range factors[1:] {
+		var q [3]int
+		for c := range q {
+			v := f[c] / maxValue
+			q[c] = int(math.Max(0, math.Min(18, math.Floor(math.Copysign(math.Sqrt(math.Abs(v)), v)*9+9.5))))
+		}
+		writeBase83(&sb, q[0]*19*19+q[1]*19+q[2], 2)
+	}
+	return sb.String()
+}
+
+func writeBase83(sb *strings.Builder, value int, length int) {
+	for i := length - 1; i >= 0; i-- {
+		digit := (value / int(math.Pow(83, float64(i)))) % 83
+		sb.WriteByte(base83[digit])
+	}
+}
+
+func srgbToLinear(v uint32) float64 {
+	x := float64(v) / 255
+	if x <= 0.04045 {
+		return x / 12.92
+	}
+	return math.Pow((x+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(v float64) int {
+	x := math.Max(0, math.Min(1, v))
+	if x <= 0.0031308 {
+		return int(x*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(x, 1/2.4)-0.055)*255 + 0.5)
+}
+
+// DominantColor returns the most common color as #rrggbb. Colors are
+// grouped by 4 high bits of each channel and averaged within the group.
+func DominantColor(img image.Image) string {
+	img = downscale(img)
+	b := img.Bounds()
+
+	type bucket struct {
+		n       int
+		r, g, b uint32
+	}
+	var buckets [1 << 12]bucket
+	best := 0
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			r, g, bl = r>>8, g>>8, bl>>8
+			i := int(r>>4<<8 | g>>4<<4 | bl>>4)
+			buckets[i].n++
+			buckets[i].r += r
+			buckets[i].g += g
+			buckets[i].b += bl
+			if buckets[i].n > buckets[best].n {
+				best = i
+			}
+		}
+	}
+
+	c := buckets[best]
+	if c.n == 0 {
+		return ""
+	}
+	n := uint32(c.n)
+	return fmt.Sprintf("#%02x%02x%02x", c.r/n, c.g/n, c.b/n)
+}
@@ -0,0 +1,31 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlurHash(t *testing.T) {
+	b, _ := os.ReadFile("../../testdata/hq.jpg")
+	hq, _ := Decode(b)
+	// Reference value of github.com/buckket/go-blurhash for the same 64x48 image
+	assert.Equal(t, "L9EySy4o?^xB_NM{IAIUyEIn-pIU", BlurHash(hq))
+
+	img := image.NewRGBA(image.Rect(0, 0, 32, 24))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{255, 0, 0, 255}), image.Point{}, draw.Src)
+	// Size flag 4x3 and DC of pure red
+	assert.Equal(t, "L", BlurHash(img)[:1])
+	assert.Equal(t, "TI:j", BlurHash(img)[2:6])
+}
+
+func TestDominantColor(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{0x20, 0x40, 0x80, 255}), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, 30, 100), image.NewUniform(color.White), image.Point{}, draw.Src)
+	assert.Equal(t, "#204080", DominantColor(img))
+}
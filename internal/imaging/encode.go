@@ -0,0 +1,97 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+)
+
+var (
+	ErrNotJPEG     = errors.New("not a JPEG image")
+	errInvalidSize = errors.New("invalid image size")
+)
+
+// Encoding of transformed images
+type Encoding int
+
+const (
+	EncodingJPEG Encoding = iota
+	EncodingPNG
+)
+
+func (e Encoding) String() string {
+	if e == EncodingPNG {
+		return "png"
+	}
+	return "jpeg"
+}
+
+// Options of Encode, zero value is baseline JPEG with JPEGQuality
+type Options struct {
+	Encoding Encoding
+	// Quality of JPEG 1-100, 0 - JPEGQuality
+	Quality     int
+	Progressive bool
+}
+
+// Encode writes image without any metadata
+func Encode(img image.Image, opts Options) ([]byte, error) {
+	var buf bytes.Buffer
+	if opts.Encoding == EncodingPNG {
+		// Best compression takes seconds on large images
+		enc := png.Encoder{CompressionLevel: png.DefaultCompression}
+		if err := enc.Encode(&buf, img); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	quality := opts.Quality
+	if quality == 0 {
+		quality = JPEGQuality
+	}
+	var err error
+	if opts.Progressive {
+		err = EncodeProgressive(&buf, img, quality)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// StripMetadata removes EXIF, ICC profiles, comments and other application
+// segments from JPEG without re-encoding. JFIF (APP0) and Adobe (APP14)
+// segments are kept as they affect decoding.
+func StripMetadata(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 {
+		return nil, ErrNotJPEG
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, 0xff, 0xd8)
+	for i := 2; ; {
+		if i+4 > len(data) || data[i] != 0xff {
+			return nil, ErrNotJPEG
+		}
+		marker := data[i+1]
+		if marker == 0xda {
+			// Entropy coded data follows SOS till the end
+			return append(out, data[i:]...), nil
+		}
+		n := int(binary.BigEndian.Uint16(data[i+2:]))
+		if n < 2 || i+2+n > len(data) {
+			return nil, ErrNotJPEG
+		}
+		isMetadata := (marker >= 0xe1 && marker <= 0xef && marker != 0xee) || marker == 0xfe
+		if !isMetadata {
+			out = append(out, data[i:i+2+n]...)
+		}
+		i += 2 + n
+	}
+}
@@ -0,0 +1,81 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// psnr of b compared to a in dB
+func psnr(a image.Image, b image.Image) float64 {
+	var sum float64
+	n := 0
+	r := a.Bounds()
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			r1, g1, b1, _ := a.At(x, y).RGBA()
+			r2, g2, b2, _ := b.At(x-r.Min.X+b.Bounds().Min.X, y-r.Min.Y+b.Bounds().Min.Y).RGBA()
+			for _, d := range []float64{
+				float64(r1>>8) - float64(r2>>8),
+				float64(g1>>8) - float64(g2>>8),
+				float64(b1>>8) - float64(b2>>8),
+			} {
+				sum += d * d
+				n++
+			}
+		}
+	}
+	return 10 * math.Log10(255*255/(sum/float64(n)))
+}
+
+func TestEncodeProgressive(t *testing.T) {
+	b, _ := os.ReadFile("../../testdata/hq.jpg")
+	src, _ := Decode(b)
+
+	// Odd size checks padding blocks
+	for _, img := range []image.Image{src, Resize(src, 101, 77, FitFill)} {
+		out, err := Encode(img, Options{Quality: 90, Progressive: true})
+		assert.Nil(t, err)
+		assert.True(t, bytes.Contains(out[:1024], []byte{0xff, 0xc2}), "SOF2 marker")
+
+		got, err := jpeg.Decode(bytes.NewReader(out))
+		assert.Nil(t, err)
+		assert.Equal(t, img.Bounds().Size(), got.Bounds().Size())
+		assert.Greater(t, psnr(img, got), 30.0)
+
+		baseline, _ := Encode(img, Options{Quality: 90})
+		assert.InDelta(t, len(baseline), len(out), float64(len(baseline))/5)
+	}
+}
+
+func TestEncodePNG(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	out, err := Encode(img, Options{Encoding: EncodingPNG})
+	assert.Nil(t, err)
+	got, format, err := image.Decode(bytes.NewReader(out))
+	assert.Nil(t, err)
+	assert.Equal(t, "png", format)
+	assert.Equal(t, img.Bounds(), got.Bounds())
+}
+
+func TestStripMetadata(t *testing.T) {
+	b, _ := os.ReadFile("../../testdata/hq.jpg")
+	exif := []byte{0xff, 0xe1, 0, 8, 'E', 'x', 'i', 'f', 0, 0}
+	comment := []byte{0xff, 0xfe, 0, 4, 'h', 'i'}
+	withMeta := append(append(append([]byte{0xff, 0xd8}, exif...), comment...), b[2:]...)
+
+	out, err := StripMetadata(withMeta)
+	assert.Nil(t, err)
+	assert.False(t, bytes.Contains(out, []byte("Exif")))
+	assert.LessOrEqual(t, len(out), len(b))
+	_, err = Decode(out)
+	assert.Nil(t, err)
+
+	_, err = StripMetadata([]byte("\x89PNG"))
+	assert.ErrorIs(t, err, ErrNotJPEG)
+}
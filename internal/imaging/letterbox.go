@@ -0,0 +1,88 @@
+package imaging
+
+import (
+	"image"
+	"image/draw"
+)
+
+const (
+	// Max channel value of bar pixels, JPEG artifacts make black bars noisy
+	letterboxThreshold = 32
+	// Share of brighter pixels tolerated in one line of a bar
+	letterboxNoise = 0.02
+)
+
+// DetectLetterbox returns content area inside dark bars on opposite sides
+// of the image, e.g. 16:9 video in 4:3 hqdefault. Bars have to be about
+// the same size on both sides, otherwise it is a dark scene. Image bounds
+// are returned if there are no bars.
+func DetectLetterbox(img image.Image) image.Rectangle {
+	b := img.Bounds()
+	content := b
+
+	isBar := func(r image.Rectangle) bool {
+		bright, limit := 0, int(letterboxNoise*float64(r.Dx()*r.Dy()))
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				cr, cg, cb, _ := img.At(x, y).RGBA()
+				if max(cr, cg, cb)>>8 > letterboxThreshold {
+					bright++
+					if bright > limit {
+						return false
+					}
+				}
+			}
+		}
+		return true
+	}
+
+	top, bottom := b.Min.Y, b.Max.Y
+	for top < bottom && isBar(image.Rect(b.Min.X, top, b.Max.X, top+1)) {
+		top++
+	}
+	for bottom > top && isBar(image.Rect(b.Min.X, bottom-1, b.Max.X, bottom)) {
+		bottom--
+	}
+	if top == bottom {
+		// Dark image
+		return b
+	}
+	if isLetterbox(top-b.Min.Y, b.Max.Y-bottom, b.Dy()) {
+		content.Min.Y, content.Max.Y = top, bottom
+	}
+
+	left, right := b.Min.X, b.Max.X
+	for left < right && isBar(image.Rect(left, content.Min.Y, left+1, content.Max.Y)) {
+		left++
+	}
+	for right > left && isBar(image.Rect(right-1, content.Min.Y, right, content.Max.Y)) {
+		right--
+	}
+	if isLetterbox(left-b.Min.X, b.Max.X-right, b.Dx()) {
+		content.Min.X, content.Max.X = left, right
+	}
+
+	return content
+}
+
+// isLetterbox checks that bars of size a and b are visible and centered
+func isLetterbox(a int, b int, size int) bool {
+	minBar := max(size/100, 2)
+	tolerance := max(size/50, 2)
+	return a >= minBar && b >= minBar && a-b <= tolerance && b-a <= tolerance
+}
+
+// Crop returns r area of image, sharing pixels if possible
+func Crop(img image.Image, r image.Rectangle) image.Image {
+	if r == img.Bounds() {
+		return img
+	}
+	if sub, ok := img.(interface {
+		SubImage(r image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(r)
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, r.Min, draw.Src)
+	return dst
+}
@@ -0,0 +1,48 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// letterboxed puts 16:9 maxres into 480x360 with black bars like hqdefault
+func letterboxed(t *testing.T) image.Image {
+	b, _ := os.ReadFile("../../testdata/maxres.jpg")
+	img, err := Decode(b)
+	assert.Nil(t, err)
+
+	dst := image.NewRGBA(image.Rect(0, 0, 480, 360))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	draw.Draw(dst, image.Rect(0, 45, 480, 315), Resize(img, 480, 270, FitFill), image.Point{}, draw.Src)
+
+	// Through JPEG for realistic noise in bars
+	out, err := Encode(dst, Options{})
+	assert.Nil(t, err)
+	img, err = Decode(out)
+	assert.Nil(t, err)
+	return img
+}
+
+func TestDetectLetterbox(t *testing.T) {
+	img := letterboxed(t)
+	r := DetectLetterbox(img)
+	assert.InDelta(t, 45, r.Min.Y, 2)
+	assert.InDelta(t, 315, r.Max.Y, 2)
+	assert.Equal(t, 0, r.Min.X)
+	assert.Equal(t, 480, r.Max.X)
+	assert.Equal(t, r.Size(), Crop(img, r).Bounds().Size())
+
+	// Dark only at the bottom is content, not letterbox
+	dst := image.NewRGBA(image.Rect(0, 0, 480, 360))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, image.Rect(0, 300, 480, 360), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	assert.Equal(t, dst.Bounds(), DetectLetterbox(dst))
+
+	black := image.NewGray(image.Rect(0, 0, 120, 90))
+	assert.Equal(t, black.Bounds(), DetectLetterbox(black))
+}
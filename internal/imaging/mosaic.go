@@ -0,0 +1,67 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+var (
+	mosaicBackground = color.White
+	// Tiles of missing images
+	mosaicEmpty       = color.Gray{0xd0}
+	captionBackground = color.NRGBA{0, 0, 0, 0xa0}
+)
+
+// MosaicOptions define grid of Mosaic, all sizes are in pixels
+type MosaicOptions struct {
+	Columns    int
+	Rows       int
+	TileWidth  int
+	TileHeight int
+	Padding    int
+}
+
+// Mosaic composes tiles into a grid row by row. Tiles are scaled to cover
+// the tile size, nil tiles are left empty. Non-empty captions are drawn
+// at the bottom of their tiles.
+func Mosaic(tiles []image.Image, captions []string, opts MosaicOptions) image.Image {
+	width := opts.Columns*opts.TileWidth + (opts.Columns+1)*opts.Padding
+	height := opts.Rows*opts.TileHeight + (opts.Rows+1)*opts.Padding
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(mosaicBackground), image.Point{}, draw.Src)
+
+	face := basicfont.Face7x13
+	for i := 0; i < len(tiles) && i < opts.Columns*opts.Rows; i++ {
+		x := opts.Padding + (i%opts.Columns)*(opts.TileWidth+opts.Padding)
+		y := opts.Padding + (i/opts.Columns)*(opts.TileHeight+opts.Padding)
+		r := image.Rect(x, y, x+opts.TileWidth, y+opts.TileHeight)
+
+		if tiles[i] == nil {
+			draw.Draw(dst, r, image.NewUniform(mosaicEmpty), image.Point{}, draw.Src)
+		} else {
+			tile := Resize(tiles[i], opts.TileWidth, opts.TileHeight, FitCover)
+			draw.Draw(dst, r, tile, tile.Bounds().Min, draw.Src)
+		}
+
+		if i < len(captions) && captions[i] != "" {
+			lineHeight := face.Metrics().Height.Ceil() + 4
+			strip := image.Rect(r.Min.X, max(r.Max.Y-lineHeight, r.Min.Y), r.Max.X, r.Max.Y)
+			draw.Draw(dst, strip, image.NewUniform(captionBackground), image.Point{}, draw.Over)
+			d := font.Drawer{
+				Dst:  dst,
+				Src:  image.NewUniform(color.White),
+				Face: face,
+				Dot:  fixed.P(strip.Min.X+4, strip.Max.Y-2-face.Metrics().Descent.Ceil()),
+			}
+			// Text is clipped to the tile
+			d.Dst = dst.SubImage(r).(*image.RGBA)
+			d.DrawString(captions[i])
+		}
+	}
+	return dst
+}
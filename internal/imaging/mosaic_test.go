@@ -0,0 +1,37 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMosaic(t *testing.T) {
+	red := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	draw.Draw(red, red.Bounds(), image.NewUniform(color.RGBA{255, 0, 0, 255}), image.Point{}, draw.Src)
+
+	img := Mosaic([]image.Image{red, nil, red}, []string{"", "", "abc"}, MosaicOptions{
+		Columns:    2,
+		Rows:       2,
+		TileWidth:  40,
+		TileHeight: 30,
+		Padding:    2,
+	})
+	assert.Equal(t, image.Rect(0, 0, 2*40+3*2, 2*30+3*2), img.Bounds())
+
+	rgba := func(x, y int) color.RGBA {
+		return color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+	}
+	assert.Equal(t, color.RGBA{255, 255, 255, 255}, rgba(0, 0))
+	// Covered, not letterboxed
+	assert.Equal(t, color.RGBA{255, 0, 0, 255}, rgba(3, 3))
+	assert.Equal(t, color.RGBA{0xd0, 0xd0, 0xd0, 255}, rgba(50, 10))
+	// Caption strip darkens the bottom of the tile
+	assert.Equal(t, color.RGBA{255, 0, 0, 255}, rgba(3, 36))
+	assert.NotEqual(t, color.RGBA{255, 0, 0, 255}, rgba(3, 62))
+	// Last cell is empty
+	assert.Equal(t, color.RGBA{255, 255, 255, 255}, rgba(60, 50))
+}
@@ -0,0 +1,29 @@
+package imaging
+
+import (
+	"image"
+)
+
+// DHash is a 64-bit perceptual difference hash: signs of horizontal
+// brightness gradients of the image scaled down to 9x8. Recompressed,
+// resized and slightly edited copies have hashes within a small Hamming
+// distance.
+func DHash(img image.Image) uint64 {
+	small := Resize(img, 9, 8, FitFill)
+	b := small.Bounds()
+	luma := func(x, y int) uint32 {
+		r, g, bl, _ := small.At(b.Min.X+x, b.Min.Y+y).RGBA()
+		return 299*r + 587*g + 114*bl
+	}
+
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if luma(x, y) < luma(x+1, y) {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
@@ -0,0 +1,23 @@
+package imaging
+
+import (
+	"math/bits"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDHash(t *testing.T) {
+	b, _ := os.ReadFile("../../testdata/maxres.jpg")
+	maxres, _ := Decode(b)
+	b, _ = os.ReadFile("../../testdata/hq.jpg")
+	hq, _ := Decode(b)
+
+	// Smaller recompressed copy is a duplicate
+	out, _ := Encode(Resize(maxres, 320, 0, FitContain), Options{Quality: 50})
+	small, _ := Decode(out)
+	assert.LessOrEqual(t, bits.OnesCount64(DHash(maxres)^DHash(small)), 4)
+
+	assert.Greater(t, bits.OnesCount64(DHash(maxres)^DHash(hq)), 16)
+}
@@ -0,0 +1,401 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"math/bits"
+)
+
+// Progressive JPEG encoder, image/jpeg writes only baseline JPEG.
+// Scans use spectral selection without successive approximation:
+// DC of all components first, then bands of AC coefficients, so
+// a blurry full image is shown before the details arrive.
+// Chroma is subsampled 4:2:0 like in image/jpeg.
+
+// unzig maps zig-zag index to natural index of coefficients
+var unzig = [64]int{
+	0, 1, 8, 16, 9, 2, 3, 10,
+	17, 24, 32, 25, 18, 11, 4, 5,
+	12, 19, 26, 33, 40, 48, 41, 34,
+	27, 20, 13, 6, 7, 14, 21, 28,
+	35, 42, 49, 56, 57, 50, 43, 36,
+	29, 22, 15, 23, 30, 37, 44, 51,
+	58, 59, 52, 45, 38, 31, 39, 46,
+	53, 60, 61, 54, 47, 55, 62, 63,
+}
+
+// Quantization tables of ITU T.81 Annex K in zig-zag order,
+// index 0 is luminance, 1 is chrominance
+var unscaledQuant = [2][64]byte{
+	{
+		16, 11, 12, 14, 12, 10, 16, 14,
+		13, 14, 18, 17, 16, 19, 24, 40,
+		26, 24, 22, 22, 24, 49, 35, 37,
+		29, 40, 58, 51, 61, 60, 57, 51,
+		56, 55, 64, 72, 92, 78, 64, 68,
+		87, 69, 55, 56, 80, 109, 81, 87,
+		95, 98, 103, 104, 103, 62, 77, 113,
+		121, 112, 100, 120, 92, 101, 103, 99,
+	},
+	{
+		17, 18, 18, 24, 21, 24, 47, 26,
+		26, 47, 99, 66, 56, 66, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+	},
+}
+
+type huffmanSpec struct {
+	// count of codes of each length from 1 to 16 bits
+	count [16]byte
+	value []byte
+}
+
+// Huffman tables of ITU T.81 Annex K: luminance DC, luminance AC,
+// chrominance DC, chrominance AC
+var huffmanSpecs = [4]huffmanSpec{
+	{
+		[16]byte{0, 1, 5, 1, 1, 1, 1, 1, 1, 0, 0, 0, 0, 0, 0, 0},
+		[]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11},
+	},
+	{
+		[16]byte{0, 2, 1, 3, 3, 2, 4, 3, 5, 5, 4, 4, 0, 0, 1, 125},
+		[]byte{
+			0x01, 0x02, 0x03, 0x00, 0x04, 0x11, 0x05, 0x12,
+			0x21, 0x31, 0x41, 0x06, 0x13, 0x51, 0x61, 0x07,
+			0x22, 0x71, 0x14, 0x32, 0x81, 0x91, 0xa1, 0x08,
+			0x23, 0x42, 0xb1, 0xc1, 0x15, 0x52, 0xd1, 0xf0,
+			0x24, 0x33, 0x62, 0x72, 0x82, 0x09, 0x0a, 0x16,
+			0x17, 0x18, 0x19, 0x1a, 0x25, 0x26, 0x27, 0x28,
+			0x29, 0x2a, 0x34, 0x35, 0x36, 0x37, 0x38, 0x39,
+			0x3a, 0x43, 0x44, 0x45, 0x46, 0x47, 0x48, 0x49,
+			0x4a, 0x53, 0x54, 0x55, 0x56, 0x57, 0x58, 0x59,
+			0x5a, 0x63, 0x64, 0x65, 0x66, 0x67, 0x68, 0x69,
+			0x6a, 0x73, 0x74, 0x75, 0x76, 0x77, 0x78, 0x79,
+			0x7a, 0x83, 0x84, 0x85, 0x86, 0x87, 0x88, 0x89,
+			0x8a, 0x92, 0x93, 0x94, 0x95, 0x96, 0x97, 0x98,
+			0x99, 0x9a, 0xa2, 0xa3, 0xa4, 0xa5, 0xa6, 0xa7,
+			0xa8, 0xa9, 0xaa, 0xb2, 0xb3, 0xb4, 0xb5, 0xb6,
+			0xb7, 0xb8, 0xb9, 0xba, 0xc2, 0xc3, 0xc4, 0xc5,
+			0xc6, 0xc7, 0xc8, 0xc9, 0xca, 0xd2, 0xd3, 0xd4,
+			0xd5, 0xd6, 0xd7, 0xd8, 0xd9, 0xda, 0xe1, 0xe2,
+			0xe3, 0xe4, 0xe5, 0xe6, 0xe7, 0xe8, 0xe9, 0xea,
+			0xf1, 0xf2, 0xf3, 0xf4, 0xf5, 0xf6, 0xf7, 0xf8,
+			0xf9, 0xfa,
+		},
+	},
+	{
+		[16]byte{0, 3, 1, 1, 1, 1, 1, 1, 1, 1, 1, 0, 0, 0, 0, 0},
+		[]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11},
+	},
+	{
+		[16]byte{0, 2, 1, 2, 4, 4, 3, 4, 7, 5, 4, 4, 0, 1, 2, 119},
+		[]byte{
+			0x00, 0x01, 0x02, 0x03, 0x11, 0x04, 0x05, 0x21,
+			0x31, 0x06, 0x12, 0x41, 0x51, 0x07, 0x61, 0x71,
+			0x13, 0x22, 0x32, 0x81, 0x08, 0x14, 0x42, 0x91,
+			0xa1, 0xb1, 0xc1, 0x09, 0x23, 0x33, 0x52, 0xf0,
+			0x15, 0x62, 0x72, 0xd1, 0x0a, 0x16, 0x24, 0x34,
+			0xe1, 0x25, 0xf1, 0x17, 0x18, 0x19, 0x1a, 0x26,
+			0x27, 0x28, 0x29, 0x2a, 0x35, 0x36, 0x37, 0x38,
+			0x39, 0x3a, 0x43, 0x44, 0x45, 0x46, 0x47, 0x48,
+			0x49, 0x4a, 0x53, 0x54, 0x55, 0x56, 0x57, 0x58,
+			0x59, 0x5a, 0x63, 0x64, 0x65, 0x66, 0x67, 0x68,
+			0x69, 0x6a, 0x73, 0x74, 0x75, 0x76, 0x77, 0x78,
+			0x79, 0x7a, 0x82, 0x83, 0x84, 0x85, 0x86, 0x87,
+			0x88, 0x89, 0x8a, 0x92, 0x93, 0x94, 0x95, 0x96,
+			0x97, 0x98, 0x99, 0x9a, 0xa2, 0xa3, 0xa4, 0xa5,
+			0xa6, 0xa7, 0xa8, 0xa9, 0xaa, 0xb2, 0xb3, 0xb4,
+			0xb5, 0xb6, 0xb7, 0xb8, 0xb9, 0xba, 0xc2, 0xc3,
+			0xc4, 0xc5, 0xc6, 0xc7, 0xc8, 0xc9, 0xca, 0xd2,
+			0xd3, 0xd4, 0xd5, 0xd6, 0xd7, 0xd8, 0xd9, 0xda,
+			0xe2, 0xe3, 0xe4, 0xe5, 0xe6, 0xe7, 0xe8, 0xe9,
+			0xea, 0xf2, 0xf3, 0xf4, 0xf5, 0xf6, 0xf7, 0xf8,
+			0xf9, 0xfa,
+		},
+	},
+}
+
+// huffmanCode is code length in the high byte and code in the low bits
+type huffmanLUT [256]uint32
+
+var huffmanLUTs = func() (luts [4]huffmanLUT) {
+	for i, spec := range huffmanSpecs {
+		code, k := uint32(0), 0
+		for n, count := range spec.count {
+			for j := 0; j < int(count); j++ {
+				luts[i][spec.value[k]] = uint32(n+1)<<24 | code
+				code++
+				k++
+			}
+			code <<= 1
+		}
+	}
+	return luts
+}()
+
+// dctCos[x][u] is cos((2x+1)uπ/16)
+var dctCos = func() (c [8][8]float64) {
+	for x := 0; x < 8; x++ {
+		for u := 0; u < 8; u++ {
+			c[x][u] = math.Cos(float64(2*x+1) * float64(u) * math.Pi / 16)
+		}
+	}
+	return c
+}()
+
+type bitWriter struct {
+	buf   *bytes.Buffer
+	bits  uint32
+	nBits uint32
+}
+
+func (w *bitWriter) emit(bits uint32, nBits uint32) {
+	nBits += w.nBits
+	bits <<= 32 - nBits
+	bits |= w.bits
+	for nBits >= 8 {
+		b := byte(bits >> 24)
+		w.buf.WriteByte(b)
+		if b == 0xff {
+			w.buf.WriteByte(0x00)
+		}
+		bits <<= 8
+		nBits -= 8
+	}
+	w.bits, w.nBits = bits, nBits
+}
+
+func (w *bitWriter) emitHuff(lut *huffmanLUT, value int32) {
+	x := lut[value]
+	w.emit(x&(1<<24-1), x>>24)
+}
+
+// emitHuffRLE emits run of zeros and the size of value with Huffman code,
+// then the value itself
+func (w *bitWriter) emitHuffRLE(lut *huffmanLUT, runLength int32, value int32) {
+	a, b := value, value
+	if a < 0 {
+		a, b = -value, value-1
+	}
+	nBits := uint32(bits.Len32(uint32(a)))
+	w.emitHuff(lut, runLength<<4|int32(nBits))
+	if nBits > 0 {
+		w.emit(uint32(b)&(1<<nBits-1), nBits)
+	}
+}
+
+// flush pads the last byte of a scan with 1 bits
+func (w *bitWriter) flush() {
+	w.emit(0x7f, 7)
+	w.bits, w.nBits = 0, 0
+}
+
+// component of the encoded image, blocks are in zig-zag order
+type component struct {
+	id      byte
+	h       int
+	table   int
+	blocksX int
+	blocksY int
+	blocks  [][64]int32
+}
+
+// EncodeProgressive writes img as progressive JPEG with quality 1-100
+func EncodeProgressive(w io.Writer, img image.Image, quality int) error {
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+	if width == 0 || height == 0 || width >= 1<<16 || height >= 1<<16 {
+		return errInvalidSize
+	}
+	quality = min(max(quality, 1), 100)
+
+	var quant [2][64]int32
+	scale := 200 - quality*2
+	if quality < 50 {
+		scale = 5000 / quality
+	}
+	for i := range quant {
+		for j, x := range unscaledQuant[i] {
+			quant[i][j] = int32(min(max((int(x)*scale+50)/100, 1), 255))
+		}
+	}
+
+	// Image is padded to whole 16x16 MCUs by repeating edge pixels
+	mcuX, mcuY := (width+15)/16, (height+15)/16
+	yPlane := make([]float64, 16*mcuX*16*mcuY)
+	cbPlane := make([]float64, 8*mcuX*8*mcuY)
+	crPlane := make([]float64, 8*mcuX*8*mcuY)
+	for y := 0; y < 16*mcuY; y++ {
+		for x := 0; x < 16*mcuX; x++ {
+			r, g, bl, _ := img.At(b.Min.X+min(x, width-1), b.Min.Y+min(y, height-1)).RGBA()
+			yy, cb, cr := color.RGBToYCbCr(uint8(r>>8), uint8(g>>8), uint8(bl>>8))
+			yPlane[y*16*mcuX+x] = float64(yy)
+			cbPlane[(y/2)*8*mcuX+x/2] += float64(cb) / 4
+			crPlane[(y/2)*8*mcuX+x/2] += float64(cr) / 4
+		}
+	}
+
+	comps := []*component{
+		{id: 1, h: 2, table: 0},
+		{id: 2, h: 1, table: 1},
+		{id: 3, h: 1, table: 1},
+	}
+	for i, plane := range [][]float64{yPlane, cbPlane, crPlane} {
+		c := comps[i]
+		c.blocksX, c.blocksY = c.h*mcuX, c.h*mcuY
+		c.blocks = make([][64]int32, c.blocksX*c.blocksY)
+		stride := 8 * c.blocksX
+		var in [64]float64
+		for by := 0; by < c.blocksY; by++ {
+			for bx := 0; bx < c.blocksX; bx++ {
+				for y := 0; y < 8; y++ {
+					for x := 0; x < 8; x++ {
+						in[y*8+x] = plane[(by*8+y)*stride+bx*8+x] - 128
+					}
+				}
+				fdct(&in, &c.blocks[by*c.blocksX+bx], &quant[c.table])
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.Write([]byte{0xff, 0xd8})
+
+	// DQT
+	buf.Write([]byte{0xff, 0xdb, 0, 2 + 2*65})
+	for i := range quant {
+		buf.WriteByte(byte(i))
+		for _, q := range quant[i] {
+			buf.WriteByte(byte(q))
+		}
+	}
+
+	// SOF2
+	buf.Write([]byte{0xff, 0xc2, 0, 8 + 3*3, 8, byte(height >> 8), byte(height), byte(width >> 8), byte(width), 3})
+	for _, c := range comps {
+		buf.Write([]byte{c.id, byte(c.h<<4 | c.h), byte(c.table)})
+	}
+
+	// DHT
+	n := 2
+	for _, s := range huffmanSpecs {
+		n += 17 + len(s.value)
+	}
+	buf.Write([]byte{0xff, 0xc4, byte(n >> 8), byte(n)})
+	for i, s := range huffmanSpecs {
+		// Class (0 - DC, 1 - AC) and table index
+		buf.WriteByte(byte((i%2)<<4 | i/2))
+		buf.Write(s.count[:])
+		buf.Write(s.value)
+	}
+
+	bw := &bitWriter{buf: &buf}
+
+	// Interleaved DC scan
+	writeSOS(&buf, comps, 0, 0)
+	var prev [3]int32
+	for my := 0; my < mcuY; my++ {
+		for mx := 0; mx < mcuX; mx++ {
+			for i, c := range comps {
+				for j := 0; j < c.h*c.h; j++ {
+					blk := &c.blocks[(c.h*my+j/c.h)*c.blocksX+c.h*mx+j%c.h]
+					bw.emitHuffRLE(&huffmanLUTs[2*c.table], 0, blk[0]-prev[i])
+					prev[i] = blk[0]
+				}
+			}
+		}
+	}
+	bw.flush()
+
+	// AC bands, low frequencies of luminance first
+	scans := []struct {
+		comp     *component
+		ss, se   int
+		pxW, pxH int
+	}{
+		{comps[0], 1, 5, width, height},
+		{comps[1], 1, 63, (width + 1) / 2, (height + 1) / 2},
+		{comps[2], 1, 63, (width + 1) / 2, (height + 1) / 2},
+		{comps[0], 6, 63, width, height},
+	}
+	for _, s := range scans {
+		c := s.comp
+		writeSOS(&buf, []*component{c}, s.ss, s.se)
+		ac := &huffmanLUTs[2*c.table+1]
+		// Non-interleaved scans skip padding blocks outside of the image
+		for by := 0; by < (s.pxH+7)/8; by++ {
+			for bx := 0; bx < (s.pxW+7)/8; bx++ {
+				blk := &c.blocks[by*c.blocksX+bx]
+				run := int32(0)
+				for k := s.ss; k <= s.se; k++ {
+					if blk[k] == 0 {
+						run++
+						continue
+					}
+					for run > 15 {
+						bw.emitHuff(ac, 0xf0)
+						run -= 16
+					}
+					bw.emitHuffRLE(ac, run, blk[k])
+					run = 0
+				}
+				if run > 0 {
+					// EOB of one block
+					bw.emitHuff(ac, 0x00)
+				}
+			}
+		}
+		bw.flush()
+	}
+
+	buf.Write([]byte{0xff, 0xd9})
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func writeSOS(buf *bytes.Buffer, comps []*component, ss int, se int) {
+	n := 6 + 2*len(comps)
+	buf.Write([]byte{0xff, 0xda, 0, byte(n), byte(len(comps))})
+	for _, c := range comps {
+		buf.Write([]byte{c.id, byte(c.table<<4 | c.table)})
+	}
+	buf.Write([]byte{byte(ss), byte(se), 0})
+}
+
+// fdct transforms level shifted samples and quantizes the coefficients
+// into zig-zag order
+func fdct(in *[64]float64, out *[64]int32, quant *[64]int32) {
+	var tmp [64]float64
+	for y := 0; y < 8; y++ {
+		for u := 0; u < 8; u++ {
+			var sum float64
+			for x := 0; x < 8; x++ {
+				sum += in[y*8+x] * dctCos[x][u]
+			}
+			tmp[y*8+u] = sum
+		}
+	}
+	for k := 0; k < 64; k++ {
+		u, v := unzig[k]%8, unzig[k]/8
+		var sum float64
+		for y := 0; y < 8; y++ {
+			sum += tmp[y*8+u] * dctCos[y][v]
+		}
+		sum /= 4
+		if u == 0 {
+			sum /= math.Sqrt2
+		}
+		if v == 0 {
+			sum /= math.Sqrt2
+		}
+		out[k] = int32(math.Round(sum / float64(quant[k])))
+	}
+}
@@ -0,0 +1,132 @@
+// Package imaging transforms thumbnails with pure Go codecs and scalers
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	ErrDecode   = errors.New("could not decode image")
+	ErrTooLarge = errors.New("image is too large")
+)
+
+// MaxDimension limits requested width and height,
+// the largest thumbnails are 1280x720
+const MaxDimension = 1280
+
+// JPEGQuality is default quality of transformed images
+const JPEGQuality = 85
+
+// Fit defines how an image is resized when both width and height are set
+type Fit int
+
+const (
+	// FitContain scales to fit inside the box keeping aspect ratio
+	FitContain Fit = iota
+	// FitCover scales to cover the box and crops the overflow from the center
+	FitCover
+	// FitFill stretches to the exact box size
+	FitFill
+)
+
+func (f Fit) String() string {
+	switch f {
+	case FitCover:
+		return "cover"
+	case FitFill:
+		return "fill"
+	default:
+		return "contain"
+	}
+}
+
+// Decode decodes JPEG, PNG or WebP image
+func Decode(data []byte) (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecode, err)
+	}
+	return img, nil
+}
+
+// DecodeLimited decodes image no larger than MaxDimension on each side.
+// Size is checked from the header before pixels are allocated.
+func DecodeLimited(data []byte) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecode, err)
+	}
+	if config.Width > MaxDimension || config.Height > MaxDimension {
+		return nil, fmt.Errorf("%w: %dx%d", ErrTooLarge, config.Width, config.Height)
+	}
+	return Decode(data)
+}
+
+// Resize scales image to width x height. Zero width or height is computed
+// from the other one keeping aspect ratio, fit is ignored then.
+func Resize(img image.Image, width int, height int, fit Fit) image.Image {
+	b := img.Bounds()
+	srcW, srcH := b.Dx(), b.Dy()
+	if srcW == 0 || srcH == 0 || (width == 0 && height == 0) {
+		return img
+	}
+
+	src := b
+	switch {
+	case height == 0:
+		height = max(srcH*width/srcW, 1)
+	case width == 0:
+		width = max(srcW*height/srcH, 1)
+	case fit == FitContain:
+		if srcW*height > srcH*width {
+			height = max(srcH*width/srcW, 1)
+		} else {
+			width = max(srcW*height/srcH, 1)
+		}
+	case fit == FitCover:
+		// Crop source to the box aspect ratio
+		if srcW*height > srcH*width {
+			w := srcH * width / height
+			src.Min.X += (srcW - w) / 2
+			src.Max.X = src.Min.X + w
+		} else {
+			h := srcW * height / width
+			src.Min.Y += (srcH - h) / 2
+			src.Max.Y = src.Min.Y + h
+		}
+	}
+
+	if src == b && width == srcW && height == srcH {
+		return img
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, src, draw.Src, nil)
+	return dst
+}
+
+// ParseSizes parses sizes like "320x180", 0 keeps aspect ratio from the other dimension
+func ParseSizes(sizes []string) ([]image.Point, error) {
+	points := make([]image.Point, 0, len(sizes))
+	for _, size := range sizes {
+		w, h, ok := strings.Cut(strings.TrimSpace(size), "x")
+		width, errW := strconv.Atoi(w)
+		height, errH := strconv.Atoi(h)
+		if !ok || errW != nil || errH != nil ||
+			width < 0 || width > MaxDimension || height < 0 || height > MaxDimension ||
+			width == 0 && height == 0 {
+			return nil, fmt.Errorf("invalid size %q, want WxH up to %d", size, MaxDimension)
+		}
+		points = append(points, image.Pt(width, height))
+	}
+	return points, nil
+}
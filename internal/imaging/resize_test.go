@@ -0,0 +1,55 @@
+package imaging
+
+import (
+	"image"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResize(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 480, 360))
+	tests := []struct {
+		width, height int
+		fit           Fit
+		want          image.Point
+	}{
+		{320, 0, FitContain, image.Pt(320, 240)},
+		{0, 90, FitCover, image.Pt(120, 90)},
+		{320, 320, FitContain, image.Pt(320, 240)},
+		{320, 320, FitCover, image.Pt(320, 320)},
+		{320, 100, FitFill, image.Pt(320, 100)},
+		{0, 0, FitFill, image.Pt(480, 360)},
+	}
+	for _, tt := range tests {
+		got := Resize(img, tt.width, tt.height, tt.fit).Bounds().Size()
+		assert.Equal(t, tt.want, got, "%dx%d %s", tt.width, tt.height, tt.fit)
+	}
+}
+
+func TestParseSizes(t *testing.T) {
+	sizes, err := ParseSizes([]string{"320x180", " 0x90"})
+	assert.Nil(t, err)
+	assert.Equal(t, []image.Point{image.Pt(320, 180), image.Pt(0, 90)}, sizes)
+
+	for _, size := range []string{"320", "0x0", "axb", "4096x100", "-1x10"} {
+		_, err := ParseSizes([]string{size})
+		assert.NotNil(t, err, size)
+	}
+}
+
+func TestDecodeEncode(t *testing.T) {
+	b, _ := os.ReadFile("../../testdata/hq.jpg")
+	img, err := Decode(b)
+	assert.Nil(t, err)
+
+	out, err := Encode(Resize(img, 160, 0, FitContain), Options{})
+	assert.Nil(t, err)
+	img, err = Decode(out)
+	assert.Nil(t, err)
+	assert.Equal(t, image.Pt(160, 120), img.Bounds().Size())
+
+	_, err = Decode([]byte("not an image"))
+	assert.ErrorIs(t, err, ErrDecode)
+}
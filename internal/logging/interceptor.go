@@ -0,0 +1,118 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+const requestIDHeader = "x-request-id"
+
+// UnaryInterceptor attaches request-scoped logger with request ID,
+// peer address and method to the context. Every line logged through it
+// also carries the duration since the request started.
+// Request ID is taken from x-request-id metadata or generated,
+// and is sent back in response header.
+func UnaryInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		ctx = withRequestLogger(ctx, logger, info.FullMethod)
+		return handler(ctx, req)
+	}
+}
+
+func StreamInterceptor(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx := withRequestLogger(ss.Context(), logger, info.FullMethod)
+		return handler(srv, &serverStream{ss, ctx})
+	}
+}
+
+// Middleware does the same for plain HTTP requests
+func Middleware(logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(requestIDHeader)
+		if requestID == "" {
+			requestID = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, requestID)
+
+		l := slog.New(&durationHandler{logger.Handler(), time.Now()}).With(
+			slog.String("request_id", requestID),
+			slog.String("peer", r.RemoteAddr),
+			slog.String("method", r.Method+" "+r.URL.Path),
+		)
+		next.ServeHTTP(w, r.WithContext(WithLogger(r.Context(), l)))
+	})
+}
+
+// incomingRequestID returns request ID from incoming metadata
+func incomingRequestID(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(requestIDHeader); len(values) > 0 && values[0] != "" {
+		return values[0]
+	}
+	return ""
+}
+
+func withRequestLogger(ctx context.Context, logger *slog.Logger, method string) context.Context {
+	start := time.Now()
+
+	requestID := incomingRequestID(ctx)
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDHeader, requestID))
+
+	peerAddr := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		peerAddr = p.Addr.String()
+	}
+
+	l := slog.New(&durationHandler{logger.Handler(), start}).With(
+		slog.String("request_id", requestID),
+		slog.String("peer", peerAddr),
+		slog.String("method", method),
+	)
+	ctx = context.WithValue(ctx, requestIDContextKey{}, requestID)
+	return WithLogger(ctx, l)
+}
+
+type requestIDContextKey struct{}
+
+// RequestIDFromContext returns request ID set by the interceptor
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey{}).(string)
+	return requestID
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
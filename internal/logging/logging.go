@@ -0,0 +1,83 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+var ErrUnknownFormat = errors.New("unknown log format")
+
+// Open returns writer for "stdout", "stderr" or a file path (appended)
+func Open(output string) (io.WriteCloser, error) {
+	switch output {
+	case "", "stdout":
+		return nopCloser{os.Stdout}, nil
+	case "stderr":
+		return nopCloser{os.Stderr}, nil
+	default:
+		return os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	}
+}
+
+func New(format string, w io.Writer, level slog.Leveler) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: level}
+	switch format {
+	case FormatText, "":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+type loggerContextKey struct{}
+
+// WithLogger returns ctx carrying request-scoped logger
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
+}
+
+// FromContext returns request-scoped logger or fallback if there is none
+func FromContext(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	if logger, ok := ctx.Value(loggerContextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return fallback
+}
+
+// durationHandler adds time elapsed since request start to every record
+type durationHandler struct {
+	slog.Handler
+	start time.Time
+}
+
+func (h *durationHandler) Handle(ctx context.Context, r slog.Record) error {
+	r.AddAttrs(slog.Duration("duration", time.Since(h.start)))
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *durationHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &durationHandler{h.Handler.WithAttrs(attrs), h.start}
+}
+
+func (h *durationHandler) WithGroup(name string) slog.Handler {
+	return &durationHandler{h.Handler.WithGroup(name), h.start}
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
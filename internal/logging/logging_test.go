@@ -0,0 +1,91 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func decode(t *testing.T, buf *bytes.Buffer) map[string]any {
+	var line map[string]any
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &line))
+	return line
+}
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(FormatJSON, &buf, slog.LevelInfo)
+	assert.Nil(t, err)
+	logger.Debug("hidden")
+	logger.Info("shown", slog.String("video_id", "dQw4w9WgXcQ"))
+	assert.Equal(t, "dQw4w9WgXcQ", decode(t, &buf)["video_id"])
+
+	_, err = New("xml", &buf, slog.LevelInfo)
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _ := New(FormatJSON, &buf, slog.LevelInfo)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-request-id", "req-1"))
+	ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 5000}})
+
+	UnaryInterceptor(logger)(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/ThumbnailService/Get"},
+		func(ctx context.Context, req any) (any, error) {
+			assert.Equal(t, "req-1", RequestIDFromContext(ctx))
+			FromContext(ctx, nil).Info("HTTP request")
+			return nil, nil
+		})
+
+	line := decode(t, &buf)
+	assert.Equal(t, "req-1", line["request_id"])
+	assert.Equal(t, "10.0.0.1:5000", line["peer"])
+	assert.Equal(t, "/ThumbnailService/Get", line["method"])
+	assert.Contains(t, line, "duration")
+}
+
+func TestUnaryInterceptorGeneratesID(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _ := New(FormatJSON, &buf, slog.LevelInfo)
+
+	UnaryInterceptor(logger)(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/ThumbnailService/Get"},
+		func(ctx context.Context, req any) (any, error) {
+			FromContext(ctx, nil).Info("HTTP request")
+			return nil, nil
+		})
+
+	assert.Len(t, decode(t, &buf)["request_id"], 32)
+}
+
+func TestMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _ := New(FormatJSON, &buf, slog.LevelInfo)
+
+	h := Middleware(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		FromContext(r.Context(), nil).Info("HTTP request")
+	}))
+	req := httptest.NewRequest("GET", "/vi/dQw4w9WgXcQ/hqdefault.jpg", nil)
+	req.Header.Set("X-Request-Id", "req-2")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, "req-2", rec.Header().Get("X-Request-Id"))
+	line := decode(t, &buf)
+	assert.Equal(t, "req-2", line["request_id"])
+	assert.Equal(t, "GET /vi/dQw4w9WgXcQ/hqdefault.jpg", line["method"])
+}
+
+func TestFromContextFallback(t *testing.T) {
+	assert.Equal(t, slog.Default(), FromContext(context.Background(), slog.Default()))
+}
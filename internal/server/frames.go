@@ -0,0 +1,88 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pegov/yt-thumbnails-go/api/thumbnail_v1"
+	"github.com/pegov/yt-thumbnails-go/internal/accesslog"
+	"github.com/pegov/yt-thumbnails-go/internal/cache"
+	"github.com/pegov/yt-thumbnails-go/internal/downloader"
+)
+
+func (s *server) GetFrames(ctx context.Context, req *pb.GetFramesRequest) (*pb.GetFramesResponse, error) {
+	logger := s.requestLogger(ctx)
+
+	videoID, err := s.extractor.ExtractVideoIDFromURL(req.Url)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "url: invalid url")
+	}
+	accesslog.SetVideoID(ctx, videoID)
+
+	if req.NoCache && req.OnlyIfCached {
+		return nil, status.Error(codes.InvalidArgument, "no_cache: can't be combined with only_if_cached")
+	}
+	if req.MaxAge < 0 {
+		return nil, status.Error(codes.InvalidArgument, "max_age: must not be negative")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(s.requestTimeout.Load()))
+	defer cancel()
+
+	getReq := &pb.GetRequest{
+		Url:          req.Url,
+		NoCache:      req.NoCache,
+		OnlyIfCached: req.OnlyIfCached,
+		MaxAge:       req.MaxAge,
+	}
+	frames := make([]*pb.GetResponse, downloader.FrameCount)
+	statuses := make([]pb.CacheStatus, downloader.FrameCount)
+	errs := make([]error, downloader.FrameCount)
+	var wg sync.WaitGroup
+	for i := range frames {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Best available capture of each frame, like the best variant under plain video ID
+			key := fmt.Sprintf("%s/frame%d", videoID, i)
+			data, meta, cacheStatus, err := s.load(ctx, logger, videoID, key, getReq, func(ctx context.Context) ([]byte, cache.Metadata, error) {
+				return s.downloadWith(ctx, logger, videoID, req.OnlyIfCached, func(ctx context.Context) (*downloader.Thumbnail, error) {
+					return s.downloader.DownloadFrame(ctx, videoID, i)
+				})
+			})
+			statuses[i], errs[i] = cacheStatus, err
+			if err == nil {
+				frames[i] = newResponse(getReq, videoID, data, meta, cacheStatus)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	res := &pb.GetFramesResponse{Url: req.Url, VideoId: videoID, Frames: []*pb.Frame{}}
+	cacheStatus := pb.CacheStatus_CACHE_STATUS_HIT
+	for i, err := range errs {
+		if status.Code(err) == codes.NotFound {
+			continue
+		} else if err != nil {
+			setCacheOutcome(ctx, statuses[i])
+			return nil, err
+		}
+		// Any download makes the whole request a miss
+		if statuses[i] != pb.CacheStatus_CACHE_STATUS_HIT {
+			cacheStatus = statuses[i]
+		}
+		res.Frames = append(res.Frames, &pb.Frame{Index: int32(i), Thumbnail: frames[i]})
+	}
+	if len(res.Frames) == 0 {
+		// Not found or not cached
+		setCacheOutcome(ctx, statuses[0])
+		return nil, errs[0]
+	}
+	setCacheOutcome(ctx, cacheStatus)
+	return res, nil
+}
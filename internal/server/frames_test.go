@@ -0,0 +1,47 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pegov/yt-thumbnails-go/api/thumbnail_v1"
+	"github.com/pegov/yt-thumbnails-go/internal/downloader"
+)
+
+func TestGetFrames(t *testing.T) {
+	ctx := context.Background()
+	d := &fakeDownloader{thumbnail: &downloader.Thumbnail{Data: hq}, frames: 3}
+	svc, c := newTestServer(t, d)
+
+	r, err := svc.GetFrames(ctx, &pb.GetFramesRequest{Url: "jNQXAC9IVRw"})
+	assert.Nil(t, err)
+	assert.Equal(t, "jNQXAC9IVRw", r.VideoId)
+	assert.Len(t, r.Frames, 3)
+	for i, f := range r.Frames {
+		assert.Equal(t, int32(i), f.Index)
+		assert.Equal(t, fmt.Sprintf("hq%d", i), f.Thumbnail.Variant)
+		assert.Equal(t, hq, f.Thumbnail.Data)
+		assert.Equal(t, pb.CacheStatus_CACHE_STATUS_MISS, f.Thumbnail.CacheStatus)
+	}
+
+	// Each frame is cached separately
+	_, _, err = c.Get(ctx, "jNQXAC9IVRw/frame2", 0)
+	assert.Nil(t, err)
+	r, err = svc.GetFrames(ctx, &pb.GetFramesRequest{Url: "jNQXAC9IVRw", OnlyIfCached: true})
+	assert.Nil(t, err)
+	assert.Len(t, r.Frames, 3)
+	assert.Equal(t, pb.CacheStatus_CACHE_STATUS_HIT, r.Frames[0].Thumbnail.CacheStatus)
+
+	_, err = svc.GetFrames(ctx, &pb.GetFramesRequest{Url: "dQw4w9WgXcQ", OnlyIfCached: true})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	d.frames = 0
+	_, err = svc.GetFrames(ctx, &pb.GetFramesRequest{Url: "dQw4w9WgXcQ"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = svc.GetFrames(ctx, &pb.GetFramesRequest{Url: "invalid url"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
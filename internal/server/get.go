@@ -6,10 +6,14 @@ import (
 	"log/slog"
 	"time"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
 	pb "github.com/pegov/yt-thumbnails-go/api/thumbnail_v1"
+	"github.com/pegov/yt-thumbnails-go/internal/accesslog"
 	"github.com/pegov/yt-thumbnails-go/internal/cache"
 	"github.com/pegov/yt-thumbnails-go/internal/downloader"
 )
@@ -18,78 +22,266 @@ var (
 	errInternal = status.Error(codes.Internal, "internal")
 )
 
+var tracer = otel.Tracer("github.com/pegov/yt-thumbnails-go/internal/server")
+
 func (s *server) Get(ctx context.Context, req *pb.GetRequest) (*pb.GetResponse, error) {
+	logger := s.requestLogger(ctx)
+
+	_, span := tracer.Start(ctx, "extractor.ExtractVideoIDFromURL")
 	videoID, err := s.extractor.ExtractVideoIDFromURL(req.Url)
 	if err != nil {
+		span.SetStatus(otelcodes.Error, err.Error())
+		span.End()
 		return nil, status.Error(codes.InvalidArgument, "url: invalid url")
 	}
+	span.SetAttributes(attribute.String("video_id", videoID))
+	span.End()
+	accesslog.SetVideoID(ctx, videoID)
+
+	if req.NoCache && req.OnlyIfCached {
+		return nil, status.Error(codes.InvalidArgument, "no_cache: can't be combined with only_if_cached")
+	}
+	if req.MaxAge < 0 {
+		return nil, status.Error(codes.InvalidArgument, "max_age: must not be negative")
+	}
+
+	// Each variant is cached separately, best available one under plain video id
+	key := videoID
+	if req.Variant != "" {
+		if !downloader.IsValidVariant(req.Variant) {
+			return nil, status.Error(codes.InvalidArgument, "variant: invalid variant")
+		}
+		key = videoID + "/" + req.Variant
+	}
+
+	tr, err := newTransform(req, s.cropLetterbox.Load())
+	if err != nil {
+		return nil, err
+	}
+	if (tr.width != 0 || tr.height != 0) && !s.isAllowedSize(tr.width, tr.height) {
+		return nil, status.Error(codes.InvalidArgument, "width: size is not allowed")
+	}
 
 	// For cache and http request
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(s.requestTimeout.Load()))
 	defer cancel()
 
-	b, err := s.cache.Get(ctx, videoID)
-	if err == nil {
-		s.logger.Info("Getting image from cache", slog.String("video_id", videoID))
-		return &pb.GetResponse{
-			Url:     req.Url,
-			VideoId: videoID,
-			Data:    b,
-		}, nil
-	} else if err != nil && err != cache.ErrNotFound {
-		if errors.Is(err, context.Canceled) {
-			s.logger.Error("Cache GET: timeout", slog.String("video_id", videoID))
-		} else {
-			s.logger.Error(
-				"Cache GET: internal error",
-				slog.String("video_id", videoID),
-				slog.Any("err", err),
-			)
+	download := func(ctx context.Context) ([]byte, cache.Metadata, error) {
+		return s.download(ctx, logger, videoID, req)
+	}
+	fetch := download
+	if !tr.isZero() {
+		// Original is cached too, other sizes are made from it
+		originalKey := key
+		key = key + "/" + tr.key()
+		fetch = func(ctx context.Context) ([]byte, cache.Metadata, error) {
+			data, meta, _, err := s.load(ctx, logger, videoID, originalKey, req, download)
+			if err != nil {
+				return nil, cache.Metadata{}, err
+			}
+			return s.transform(ctx, logger, videoID, tr, data, meta)
 		}
-		s.stopOnInternalError(err)
-		return nil, errInternal
 	}
 
-	s.semaphore <- struct{}{}
-	s.logger.Info("HTTP request", slog.String("video_id", videoID))
-	b, err = s.downloader.DownloadThumbnail(ctx, videoID)
-	<-s.semaphore
+	data, meta, cacheStatus, err := s.load(ctx, logger, videoID, key, req, fetch)
+	setCacheOutcome(ctx, cacheStatus)
 	if err != nil {
-		switch err {
-		case downloader.ErrNotFound:
-			s.logger.Info("HTTP request: not found", slog.String("video_id", videoID))
-			return nil, status.Error(codes.NotFound, "not found")
-		case downloader.ErrTimeout:
-			s.logger.Error("HTTP request: timeout", slog.String("video_id", videoID))
-			return nil, status.Error(codes.DeadlineExceeded, "timeout")
-		default:
-			s.logger.Error(
-				"HTTP request: internal error",
-				slog.String("video_id", videoID),
-				slog.Any("err", err),
-			)
-			return nil, errInternal
+		return nil, err
+	}
+
+	return newResponse(req, videoID, data, meta, cacheStatus), nil
+}
+
+func setCacheOutcome(ctx context.Context, cacheStatus pb.CacheStatus) {
+	switch cacheStatus {
+	case pb.CacheStatus_CACHE_STATUS_HIT:
+		accesslog.SetCacheOutcome(ctx, accesslog.CacheHit)
+	case pb.CacheStatus_CACHE_STATUS_MISS:
+		accesslog.SetCacheOutcome(ctx, accesslog.CacheMiss)
+	case pb.CacheStatus_CACHE_STATUS_STALE:
+		accesslog.SetCacheOutcome(ctx, accesslog.CacheStale)
+	case pb.CacheStatus_CACHE_STATUS_BYPASS:
+		accesslog.SetCacheOutcome(ctx, accesslog.CacheBypass)
+	}
+}
+
+// load returns image cached under key or calls fetch and caches its result.
+// Cache status is returned even with error.
+func (s *server) load(
+	ctx context.Context,
+	logger *slog.Logger,
+	videoID string,
+	key string,
+	req *pb.GetRequest,
+	fetch func(ctx context.Context) ([]byte, cache.Metadata, error),
+) ([]byte, cache.Metadata, pb.CacheStatus, error) {
+	cacheStatus := pb.CacheStatus_CACHE_STATUS_BYPASS
+	if !req.NoCache {
+		cacheCtx, span := tracer.Start(ctx, "cache.Get")
+		b, meta, err := s.cache.Get(cacheCtx, key, time.Duration(req.MaxAge)*time.Second)
+		span.SetAttributes(attribute.Bool("hit", err == nil))
+		span.End()
+		if err == nil {
+			return b, s.backfillMetadata(ctx, logger, key, meta.Variant, b, meta), pb.CacheStatus_CACHE_STATUS_HIT, nil
+		} else if errors.Is(err, cache.ErrExpired) {
+			cacheStatus = pb.CacheStatus_CACHE_STATUS_STALE
+		} else if errors.Is(err, cache.ErrNotFound) {
+			cacheStatus = pb.CacheStatus_CACHE_STATUS_MISS
+		} else {
+			return nil, cache.Metadata{}, pb.CacheStatus_CACHE_STATUS_UNSPECIFIED, s.cacheError(logger, "GET", videoID, err)
 		}
 	}
 
-	err = s.cache.Set(ctx, videoID, b, time.Now().Unix())
+	data, meta, err := fetch(ctx)
 	if err != nil {
-		if errors.Is(err, context.Canceled) {
-			s.logger.Error("Cache SET: timeout", slog.String("video_id", videoID))
-		} else {
-			s.logger.Error(
-				"Cache SET: internal error",
-				slog.String("video_id", videoID),
-				slog.Any("err", err),
-			)
+		return nil, cache.Metadata{}, cacheStatus, err
+	}
+	// Fetch may outlive the deadline, nothing is cached then
+	if err := ctx.Err(); err != nil {
+		logger.Error("Fetch: timeout", slog.String("video_id", videoID))
+		return nil, cache.Metadata{}, cacheStatus, status.FromContextError(err).Err()
+	}
+
+	cacheCtx, span := tracer.Start(ctx, "cache.Set")
+	err = s.cache.Set(cacheCtx, key, data, meta)
+	if err != nil {
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+	span.End()
+	if err != nil {
+		return nil, cache.Metadata{}, cacheStatus, s.cacheError(logger, "SET", videoID, err)
+	}
+
+	return data, meta, cacheStatus, nil
+}
+
+// download gets thumbnail from upstream unless only cached one is accepted
+func (s *server) download(
+	ctx context.Context,
+	logger *slog.Logger,
+	videoID string,
+	req *pb.GetRequest,
+) ([]byte, cache.Metadata, error) {
+	return s.downloadWith(ctx, logger, videoID, req.OnlyIfCached, func(ctx context.Context) (*downloader.Thumbnail, error) {
+		if req.Variant == "" {
+			return s.downloader.DownloadThumbnail(ctx, videoID)
 		}
-		s.stopOnInternalError(err)
-		return nil, errInternal
+		return s.downloader.DownloadVariant(ctx, videoID, req.Variant)
+	})
+}
+
+// downloadWith calls get within the limit of parallel upstream requests
+func (s *server) downloadWith(
+	ctx context.Context,
+	logger *slog.Logger,
+	videoID string,
+	onlyIfCached bool,
+	get func(ctx context.Context) (*downloader.Thumbnail, error),
+) ([]byte, cache.Metadata, error) {
+	if onlyIfCached {
+		return nil, cache.Metadata{}, status.Error(codes.NotFound, "not cached")
+	}
+
+	_, span := tracer.Start(ctx, "semaphore.Wait")
+	err := s.semaphore.Acquire(ctx)
+	span.End()
+	if err != nil {
+		logger.Warn("HTTP request: waiting for semaphore", slog.String("video_id", videoID), slog.Any("err", err))
+		return nil, cache.Metadata{}, status.FromContextError(err).Err()
+	}
+	logger.Info("HTTP request", slog.String("video_id", videoID))
+	thumbnail, err := get(ctx)
+	s.semaphore.Release()
+	if err != nil {
+		return nil, cache.Metadata{}, upstreamError(logger, videoID, err)
+	}
+
+	return thumbnail.Data, newMetadata(thumbnail, time.Now()), nil
+}
+
+// transform processes original image, result keeps its source and age
+func (s *server) transform(
+	ctx context.Context,
+	logger *slog.Logger,
+	videoID string,
+	tr transform,
+	data []byte,
+	meta cache.Metadata,
+) ([]byte, cache.Metadata, error) {
+	_, span := tracer.Start(ctx, "transforms.Wait")
+	err := s.transforms.Acquire(ctx)
+	span.End()
+	if err != nil {
+		logger.Warn("Transform: waiting for semaphore", slog.String("video_id", videoID), slog.Any("err", err))
+		return nil, cache.Metadata{}, status.FromContextError(err).Err()
+	}
+	defer s.transforms.Release()
+
+	_, span = tracer.Start(ctx, "imaging.Transform")
+	span.SetAttributes(attribute.String("transform", tr.key()))
+	defer span.End()
+
+	out, crop, err := tr.apply(data)
+	if err != nil {
+		span.SetStatus(otelcodes.Error, err.Error())
+		logger.Error(
+			"Transform: internal error",
+			slog.String("video_id", videoID),
+			slog.Any("err", err),
+		)
+		return nil, cache.Metadata{}, errInternal
 	}
 
-	return &pb.GetResponse{
-		Url:     req.Url,
-		VideoId: videoID,
-		Data:    b,
-	}, nil
+	t := &downloader.Thumbnail{Data: out, Variant: meta.Variant, URL: meta.SourceURL}
+	outMeta := newMetadata(t, time.Unix(meta.FetchedAt, 0))
+	// Only originals are searched for similar thumbnails
+	outMeta.PHash = ""
+	if !crop.Empty() {
+		outMeta.Crop = &cache.Rect{X: crop.Min.X, Y: crop.Min.Y, Width: crop.Dx(), Height: crop.Dy()}
+	}
+	return out, outMeta, nil
+}
+
+// cacheError logs cache error and converts it to status. Only failures
+// of the cache itself stop the server, not timeouts of the request.
+func (s *server) cacheError(logger *slog.Logger, op string, videoID string, err error) error {
+	if isContextError(err) {
+		logger.Error("Cache "+op+": timeout", slog.String("video_id", videoID))
+		return status.FromContextError(err).Err()
+	}
+	logger.Error(
+		"Cache "+op+": internal error",
+		slog.String("video_id", videoID),
+		slog.Any("err", err),
+	)
+	s.stopOnInternalError(err)
+	return errInternal
+}
+
+// upstreamError logs downloader error and converts it to status
+func upstreamError(logger *slog.Logger, videoID string, err error) error {
+	switch {
+	case errors.Is(err, downloader.ErrNotFound):
+		logger.Info("HTTP request: not found", slog.String("video_id", videoID), slog.Any("err", err))
+		return status.Error(codes.NotFound, "not found")
+	case errors.Is(err, downloader.ErrCircuitOpen):
+		logger.Warn("HTTP request: circuit open", slog.String("video_id", videoID))
+		return status.Error(codes.Unavailable, "upstream unavailable")
+	case errors.Is(err, downloader.ErrInvalidImage):
+		logger.Warn("HTTP request: invalid image", slog.String("video_id", videoID), slog.Any("err", err))
+		return status.Error(codes.Unavailable, "upstream returned invalid image")
+	case errors.Is(err, downloader.ErrTooLarge):
+		logger.Warn("HTTP request: response too large", slog.String("video_id", videoID))
+		return status.Error(codes.Unavailable, "upstream image too large")
+	case errors.Is(err, downloader.ErrTimeout):
+		logger.Error("HTTP request: timeout", slog.String("video_id", videoID))
+		return status.Error(codes.DeadlineExceeded, "timeout")
+	default:
+		logger.Error(
+			"HTTP request: internal error",
+			slog.String("video_id", videoID),
+			slog.Any("err", err),
+		)
+		return errInternal
+	}
 }
@@ -2,11 +2,15 @@ package server
 
 import (
 	"context"
+	"fmt"
+	"image"
+	"image/draw"
 	"log"
 	"log/slog"
 	"net"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
@@ -14,11 +18,14 @@ import (
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
 
 	pb "github.com/pegov/yt-thumbnails-go/api/thumbnail_v1"
+	"github.com/pegov/yt-thumbnails-go/internal/cache"
 	"github.com/pegov/yt-thumbnails-go/internal/cache/sqlite"
 	"github.com/pegov/yt-thumbnails-go/internal/downloader"
 	"github.com/pegov/yt-thumbnails-go/internal/extractor"
+	"github.com/pegov/yt-thumbnails-go/internal/imaging"
 )
 
 type pair struct {
@@ -30,6 +37,8 @@ type pair struct {
 
 var wantBytes, _ = os.ReadFile("../../testdata/maxres.jpg")
 
+var hq, _ = os.ReadFile("../../testdata/hq.jpg")
+
 var pairs = []pair{
 	{"https://www.youtube.com/watch?v=dQw4w9WgXcQ", "dQw4w9WgXcQ", codes.OK, wantBytes},
 	{"https://www.youtube.com/watch?x=dQw4w9WgXcQ", "", codes.InvalidArgument, nil},
@@ -48,8 +57,8 @@ func TestThumbnailService_Get(t *testing.T) {
 	})
 
 	shutdown := make(chan struct{}, 1)
-	c, _ := sqlite.New(context.Background(), ":memory:")
-	svc := NewServer(slog.Default(), c, extractor.RegexExtractor{}, downloader.MaxResOrHqDownloader{}, 1, shutdown)
+	c, _ := sqlite.New(context.Background(), ":memory:", 24*time.Hour)
+	svc := NewServer(slog.Default(), c, extractor.RegexExtractor{}, downloader.MaxResOrHqDownloader{}, 1, 5*time.Second, shutdown)
 
 	pb.RegisterThumbnailServiceServer(srv, svc)
 
@@ -85,3 +94,271 @@ func TestThumbnailService_Get(t *testing.T) {
 		}
 	}
 }
+
+type fakeDownloader struct {
+	thumbnail *downloader.Thumbnail
+	// frames available, the rest are not found
+	frames int
+	// headErrs are returned by HeadVariant for their variants
+	headErrs map[string]error
+}
+
+func (d *fakeDownloader) DownloadThumbnail(ctx context.Context, videoID string) (*downloader.Thumbnail, error) {
+	return d.thumbnail, nil
+}
+
+func (d *fakeDownloader) DownloadVariant(ctx context.Context, videoID string, variant string) (*downloader.Thumbnail, error) {
+	return d.thumbnail, nil
+}
+
+func (d *fakeDownloader) DownloadFrame(ctx context.Context, videoID string, index int) (*downloader.Thumbnail, error) {
+	if index >= d.frames {
+		return nil, downloader.ErrNotFound
+	}
+	return &downloader.Thumbnail{Data: d.thumbnail.Data, Variant: fmt.Sprintf("hq%d", index)}, nil
+}
+
+// HeadVariant reports only the fake thumbnail variant as available
+func (d *fakeDownloader) HeadVariant(ctx context.Context, videoID string, variant string) (*downloader.Head, error) {
+	if err, ok := d.headErrs[variant]; ok {
+		return nil, err
+	}
+	if variant != d.thumbnail.Variant {
+		return nil, downloader.ErrNotFound
+	}
+	return &downloader.Head{
+		Variant:     variant,
+		URL:         d.thumbnail.URL,
+		ContentType: "image/jpeg",
+		Size:        int64(len(d.thumbnail.Data)),
+	}, nil
+}
+
+type countingDownloader struct {
+	downloader.MaxResOrHqDownloader
+	calls int
+}
+
+func (d *countingDownloader) DownloadThumbnail(ctx context.Context, videoID string) (*downloader.Thumbnail, error) {
+	return d.DownloadVariant(ctx, videoID, downloader.VariantMaxRes)
+}
+
+func (d *countingDownloader) DownloadVariant(ctx context.Context, videoID string, variant string) (*downloader.Thumbnail, error) {
+	d.calls++
+	return &downloader.Thumbnail{
+		Data:    []byte(fmt.Sprintf("%s-%d", videoID, d.calls)),
+		Variant: variant,
+		URL:     "https://i.ytimg.com/vi/" + videoID + "/" + variant + ".jpg",
+	}, nil
+}
+
+// newTestServer creates server with in-memory cache
+func newTestServer(t *testing.T, d Downloader) (*server, *sqlite.SQLiteCache) {
+	c, err := sqlite.New(context.Background(), ":memory:", 24*time.Hour)
+	if err != nil {
+		t.Fatalf("sqlite.New %v", err)
+	}
+	t.Cleanup(c.Close)
+	return NewServer(slog.Default(), c, extractor.RegexExtractor{}, d, 1, 5*time.Second, make(chan struct{}, 1)), c
+}
+
+func TestGetCacheControl(t *testing.T) {
+	ctx := context.Background()
+	d := &countingDownloader{}
+	svc, c := newTestServer(t, d)
+	url := "dQw4w9WgXcQ"
+
+	_, err := svc.Get(ctx, &pb.GetRequest{Url: url, OnlyIfCached: true})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	r, err := svc.Get(ctx, &pb.GetRequest{Url: url})
+	assert.Nil(t, err)
+	assert.Equal(t, pb.CacheStatus_CACHE_STATUS_MISS, r.CacheStatus)
+
+	r, err = svc.Get(ctx, &pb.GetRequest{Url: url, OnlyIfCached: true})
+	assert.Nil(t, err)
+	assert.Equal(t, pb.CacheStatus_CACHE_STATUS_HIT, r.CacheStatus)
+	assert.Equal(t, []byte(url+"-1"), r.Data)
+
+	r, err = svc.Get(ctx, &pb.GetRequest{Url: url, NoCache: true})
+	assert.Nil(t, err)
+	assert.Equal(t, pb.CacheStatus_CACHE_STATUS_BYPASS, r.CacheStatus)
+	assert.Equal(t, []byte(url+"-2"), r.Data)
+
+	// Refreshed entry is served afterwards
+	r, err = svc.Get(ctx, &pb.GetRequest{Url: url})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(url+"-2"), r.Data)
+
+	c.Set(ctx, url, []byte("old"), cache.Metadata{FetchedAt: time.Now().Add(-time.Hour).Unix()})
+	r, err = svc.Get(ctx, &pb.GetRequest{Url: url, MaxAge: 7200})
+	assert.Nil(t, err)
+	assert.Equal(t, pb.CacheStatus_CACHE_STATUS_HIT, r.CacheStatus)
+	assert.InDelta(t, 3600, r.Age, 5)
+
+	r, err = svc.Get(ctx, &pb.GetRequest{Url: url, MaxAge: 60})
+	assert.Nil(t, err)
+	assert.Equal(t, pb.CacheStatus_CACHE_STATUS_STALE, r.CacheStatus)
+	assert.Equal(t, int64(0), r.Age)
+
+	_, err = svc.Get(ctx, &pb.GetRequest{Url: url, NoCache: true, OnlyIfCached: true})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = svc.Get(ctx, &pb.GetRequest{Url: url, MaxAge: -1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGetMetadata(t *testing.T) {
+	ctx := context.Background()
+	d := &fakeDownloader{thumbnail: &downloader.Thumbnail{
+		Data:    hq,
+		Variant: downloader.VariantHq,
+		URL:     "https://i.ytimg.com/vi/jNQXAC9IVRw/hqdefault.jpg",
+	}}
+	svc, _ := newTestServer(t, d)
+
+	miss, err := svc.Get(ctx, &pb.GetRequest{Url: "jNQXAC9IVRw"})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(480), miss.Width)
+	assert.Equal(t, int32(360), miss.Height)
+	assert.Equal(t, "image/jpeg", miss.ContentType)
+	assert.Equal(t, int64(len(hq)), miss.ByteSize)
+	assert.Len(t, miss.Sha256, 64)
+	assert.Equal(t, downloader.VariantHq, miss.Variant)
+	assert.Equal(t, d.thumbnail.URL, miss.SourceUrl)
+	assert.Len(t, miss.Blurhash, 28)
+	assert.Regexp(t, "^#[0-9a-f]{6}$", miss.DominantColor)
+
+	hit, err := svc.Get(ctx, &pb.GetRequest{Url: "jNQXAC9IVRw"})
+	assert.Nil(t, err)
+	assert.Equal(t, pb.CacheStatus_CACHE_STATUS_HIT, hit.CacheStatus)
+	miss.CacheStatus, miss.Age, hit.Age = hit.CacheStatus, 0, 0
+	assert.True(t, proto.Equal(miss, hit))
+}
+
+func TestGetBackfillsMetadata(t *testing.T) {
+	ctx := context.Background()
+	svc, c := newTestServer(t, &fakeDownloader{})
+	// Entry cached before metadata was stored
+	fetchedAt := time.Now().Add(-time.Hour).Unix()
+	c.Set(ctx, "jNQXAC9IVRw", hq, cache.Metadata{FetchedAt: fetchedAt})
+
+	r, err := svc.Get(ctx, &pb.GetRequest{Url: "jNQXAC9IVRw"})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(480), r.Width)
+	assert.NotEmpty(t, r.Blurhash)
+
+	_, meta, err := c.Get(ctx, "jNQXAC9IVRw", 0)
+	assert.Nil(t, err)
+	assert.Len(t, meta.SHA256, 64)
+	assert.NotEmpty(t, meta.BlurHash)
+	assert.NotEmpty(t, meta.PHash)
+	assert.Equal(t, fetchedAt, meta.FetchedAt)
+}
+
+func TestGetResize(t *testing.T) {
+	ctx := context.Background()
+	d := &fakeDownloader{thumbnail: &downloader.Thumbnail{Data: hq, Variant: downloader.VariantHq}}
+	svc, _ := newTestServer(t, d)
+	req := &pb.GetRequest{Url: "jNQXAC9IVRw", Width: 320, Height: 320, Fit: pb.Fit_FIT_COVER}
+
+	r, err := svc.Get(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, pb.CacheStatus_CACHE_STATUS_MISS, r.CacheStatus)
+	assert.Equal(t, int32(320), r.Width)
+	assert.Equal(t, int32(320), r.Height)
+	assert.Equal(t, downloader.VariantHq, r.Variant)
+
+	r, err = svc.Get(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, pb.CacheStatus_CACHE_STATUS_HIT, r.CacheStatus)
+
+	// Original is cached, other sizes don't need upstream
+	d.thumbnail = nil
+	r, err = svc.Get(ctx, &pb.GetRequest{Url: "jNQXAC9IVRw", Width: 240})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(180), r.Height)
+	r, err = svc.Get(ctx, &pb.GetRequest{Url: "jNQXAC9IVRw"})
+	assert.Nil(t, err)
+	assert.Equal(t, hq, r.Data)
+
+	_, err = svc.Get(ctx, &pb.GetRequest{Url: "jNQXAC9IVRw", Width: -1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = svc.Get(ctx, &pb.GetRequest{Url: "jNQXAC9IVRw", Width: 100, Fit: 10})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	svc.SetAllowedSizes([]image.Point{image.Pt(240, 0)})
+	_, err = svc.Get(ctx, &pb.GetRequest{Url: "jNQXAC9IVRw", Width: 240})
+	assert.Nil(t, err)
+	_, err = svc.Get(ctx, &pb.GetRequest{Url: "jNQXAC9IVRw", Width: 241})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGetTimeoutKeepsServing(t *testing.T) {
+	ctx := context.Background()
+	d := &fakeDownloader{thumbnail: &downloader.Thumbnail{Data: hq, Variant: downloader.VariantHq}}
+	svc, c := newTestServer(t, d)
+	svc.SetRequestTimeout(time.Nanosecond)
+
+	_, err := svc.Get(ctx, &pb.GetRequest{Url: "jNQXAC9IVRw", Width: 320, Format: &pb.Format{Encoding: pb.Encoding_ENCODING_PNG}})
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.Len(t, svc.shutdown, 0)
+	_, _, err = c.Get(ctx, "jNQXAC9IVRw/320x0-contain,png", 0)
+	assert.ErrorIs(t, err, cache.ErrNotFound)
+}
+
+func TestGetCropLetterbox(t *testing.T) {
+	ctx := context.Background()
+	maxres, _ := imaging.Decode(wantBytes)
+	img := image.NewRGBA(image.Rect(0, 0, 480, 360))
+	draw.Draw(img, image.Rect(0, 45, 480, 315), imaging.Resize(maxres, 480, 270, imaging.FitFill), image.Point{}, draw.Src)
+	hq, _ := imaging.Encode(img, imaging.Options{})
+	d := &fakeDownloader{thumbnail: &downloader.Thumbnail{Data: hq, Variant: downloader.VariantHq}}
+	svc, _ := newTestServer(t, d)
+
+	r, err := svc.Get(ctx, &pb.GetRequest{Url: "dQw4w9WgXcQ", Width: 320, CropLetterbox: proto.Bool(true)})
+	assert.Nil(t, err)
+	assert.InDelta(t, 45, r.Crop.Y, 2)
+	assert.InDelta(t, 270, r.Crop.Height, 4)
+	assert.Equal(t, int32(480), r.Crop.Width)
+	assert.InDelta(t, 180, r.Height, 3)
+
+	// Server default applies unless request says otherwise
+	svc.SetCropLetterbox(true)
+	r, err = svc.Get(ctx, &pb.GetRequest{Url: "dQw4w9WgXcQ"})
+	assert.Nil(t, err)
+	assert.NotNil(t, r.Crop)
+	r, err = svc.Get(ctx, &pb.GetRequest{Url: "dQw4w9WgXcQ", CropLetterbox: proto.Bool(false)})
+	assert.Nil(t, err)
+	assert.Nil(t, r.Crop)
+	assert.Equal(t, hq, r.Data)
+}
+
+func TestGetFormat(t *testing.T) {
+	ctx := context.Background()
+	d := &fakeDownloader{thumbnail: &downloader.Thumbnail{Data: hq, Variant: downloader.VariantHq}}
+	svc, _ := newTestServer(t, d)
+	url := "jNQXAC9IVRw"
+
+	r, err := svc.Get(ctx, &pb.GetRequest{Url: url, Format: &pb.Format{Encoding: pb.Encoding_ENCODING_PNG}})
+	assert.Nil(t, err)
+	assert.Equal(t, "image/png", r.ContentType)
+	assert.Equal(t, int32(480), r.Width)
+
+	r, err = svc.Get(ctx, &pb.GetRequest{Url: url, Format: &pb.Format{Quality: 50, Progressive: true}})
+	assert.Nil(t, err)
+	assert.Equal(t, "image/jpeg", r.ContentType)
+	assert.Less(t, r.ByteSize, int64(len(hq)))
+
+	// Original is kept as is
+	r, err = svc.Get(ctx, &pb.GetRequest{Url: url, Format: &pb.Format{Encoding: pb.Encoding_ENCODING_JPEG}})
+	assert.Nil(t, err)
+	assert.Equal(t, hq, r.Data)
+	r, err = svc.Get(ctx, &pb.GetRequest{Url: url})
+	assert.Nil(t, err)
+	assert.Equal(t, hq, r.Data)
+
+	_, err = svc.Get(ctx, &pb.GetRequest{Url: url, Format: &pb.Format{Quality: 101}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = svc.Get(ctx, &pb.GetRequest{Url: url, Format: &pb.Format{Encoding: pb.Encoding_ENCODING_PNG, Progressive: true}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pegov/yt-thumbnails-go/api/thumbnail_v1"
+	"github.com/pegov/yt-thumbnails-go/internal/accesslog"
+	"github.com/pegov/yt-thumbnails-go/internal/cache"
+	"github.com/pegov/yt-thumbnails-go/internal/downloader"
+)
+
+func (s *server) Head(ctx context.Context, req *pb.HeadRequest) (*pb.HeadResponse, error) {
+	logger := s.requestLogger(ctx)
+
+	videoID, err := s.extractor.ExtractVideoIDFromURL(req.Url)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "url: invalid url")
+	}
+	accesslog.SetVideoID(ctx, videoID)
+
+	variants := req.Variants
+	if len(variants) == 0 {
+		variants = downloader.Variants
+	}
+	for _, variant := range variants {
+		if !downloader.IsValidVariant(variant) {
+			return nil, status.Error(codes.InvalidArgument, "variants: invalid variant")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(s.requestTimeout.Load()))
+	defer cancel()
+
+	infos := make([]*pb.VariantInfo, len(variants))
+	var missing []int
+	for i, variant := range variants {
+		info, err := s.headFromCache(ctx, logger, videoID, variant)
+		if err != nil {
+			return nil, s.cacheError(logger, "GET", videoID, err)
+		}
+		if info == nil {
+			missing = append(missing, i)
+		}
+		infos[i] = info
+	}
+	if len(missing) == 0 {
+		accesslog.SetCacheOutcome(ctx, accesslog.CacheHit)
+	} else {
+		accesslog.SetCacheOutcome(ctx, accesslog.CacheMiss)
+	}
+
+	// Upstream HEAD requests share the limit with downloads
+	errs := make([]error, len(variants))
+	var wg sync.WaitGroup
+	for _, i := range missing {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			infos[i], errs[i] = s.headFromUpstream(ctx, videoID, variants[i])
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		switch {
+		case err == nil:
+		case isContextError(err):
+			// The whole request is canceled or out of time
+			logger.Warn("HTTP request: waiting for semaphore", slog.String("video_id", videoID), slog.Any("err", err))
+			return nil, status.FromContextError(err).Err()
+		default:
+			// Other variants are still answered
+			infos[i] = &pb.VariantInfo{
+				Variant: variants[i],
+				Error:   status.Convert(upstreamError(logger, videoID, err)).Message(),
+			}
+		}
+	}
+
+	return &pb.HeadResponse{
+		Url:      req.Url,
+		VideoId:  videoID,
+		Variants: infos,
+	}, nil
+}
+
+// headFromCache returns nil info if the variant is not cached. Best available
+// thumbnail is cached under plain video ID, so it is checked as well.
+func (s *server) headFromCache(ctx context.Context, logger *slog.Logger, videoID string, variant string) (*pb.VariantInfo, error) {
+	ctx, span := tracer.Start(ctx, "cache.Get")
+	defer span.End()
+
+	for _, key := range []string{videoID + "/" + variant, videoID} {
+		b, meta, err := s.cache.Get(ctx, key, 0)
+		if errors.Is(err, cache.ErrNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		keyVariant := ""
+		if key != videoID {
+			keyVariant = variant
+		}
+		meta = s.backfillMetadata(ctx, logger, key, keyVariant, b, meta)
+		if meta.Variant != variant {
+			continue
+		}
+
+		span.SetAttributes(attribute.Bool("hit", true))
+		return &pb.VariantInfo{
+			Variant:     variant,
+			Available:   true,
+			Cached:      true,
+			Width:       int32(meta.Width),
+			Height:      int32(meta.Height),
+			ContentType: meta.ContentType,
+			ByteSize:    meta.Size,
+			Age:         max(time.Now().Unix()-meta.FetchedAt, 0),
+
+			Blurhash:      meta.BlurHash,
+			DominantColor: meta.DominantColor,
+		}, nil
+	}
+	span.SetAttributes(attribute.Bool("hit", false))
+	return nil, nil
+}
+
+func (s *server) headFromUpstream(ctx context.Context, videoID string, variant string) (*pb.VariantInfo, error) {
+	if err := s.semaphore.Acquire(ctx); err != nil {
+		return nil, err
+	}
+	h, err := s.downloader.HeadVariant(ctx, videoID, variant)
+	s.semaphore.Release()
+	if errors.Is(err, downloader.ErrNotFound) {
+		return &pb.VariantInfo{Variant: variant}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &pb.VariantInfo{
+		Variant:     variant,
+		Available:   true,
+		Width:       int32(h.Width),
+		Height:      int32(h.Height),
+		ContentType: h.ContentType,
+		ByteSize:    h.Size,
+	}, nil
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pegov/yt-thumbnails-go/api/thumbnail_v1"
+	"github.com/pegov/yt-thumbnails-go/internal/downloader"
+)
+
+func TestHead(t *testing.T) {
+	ctx := context.Background()
+	d := &fakeDownloader{thumbnail: &downloader.Thumbnail{Data: hq, Variant: downloader.VariantHq}}
+	svc, _ := newTestServer(t, d)
+	req := &pb.HeadRequest{
+		Url:      "jNQXAC9IVRw",
+		Variants: []string{downloader.VariantMaxRes, downloader.VariantHq},
+	}
+
+	r, err := svc.Head(ctx, req)
+	assert.Nil(t, err)
+	assert.Len(t, r.Variants, 2)
+	assert.False(t, r.Variants[0].Available)
+	assert.True(t, r.Variants[1].Available)
+	assert.False(t, r.Variants[1].Cached)
+
+	// Best available thumbnail is cached under plain video ID
+	_, err = svc.Get(ctx, &pb.GetRequest{Url: "jNQXAC9IVRw"})
+	assert.Nil(t, err)
+	r, err = svc.Head(ctx, req)
+	assert.Nil(t, err)
+	assert.False(t, r.Variants[0].Cached)
+	assert.True(t, r.Variants[1].Cached)
+	assert.Equal(t, int32(480), r.Variants[1].Width)
+	assert.Equal(t, int64(len(hq)), r.Variants[1].ByteSize)
+	assert.NotEmpty(t, r.Variants[1].Blurhash)
+	assert.NotEmpty(t, r.Variants[1].DominantColor)
+
+	// Upstream error of one variant doesn't fail the others
+	d.headErrs = map[string]error{downloader.VariantMaxRes: downloader.ErrCircuitOpen}
+	r, err = svc.Head(ctx, &pb.HeadRequest{
+		Url:      "jNQXAC9IVRw",
+		Variants: []string{downloader.VariantMaxRes, downloader.VariantSd},
+	})
+	assert.Nil(t, err)
+	assert.False(t, r.Variants[0].Available)
+	assert.Equal(t, "upstream unavailable", r.Variants[0].Error)
+	assert.False(t, r.Variants[1].Available)
+	assert.Empty(t, r.Variants[1].Error)
+
+	_, err = svc.Head(ctx, &pb.HeadRequest{Url: "jNQXAC9IVRw", Variants: []string{"hq720"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	pb "github.com/pegov/yt-thumbnails-go/api/thumbnail_v1"
+	"github.com/pegov/yt-thumbnails-go/internal/cache"
+	"github.com/pegov/yt-thumbnails-go/internal/downloader"
+	"github.com/pegov/yt-thumbnails-go/internal/imaging"
+)
+
+// newMetadata describes downloaded thumbnail. It is computed once
+// and stored in cache next to the image.
+func newMetadata(t *downloader.Thumbnail, fetchedAt time.Time) cache.Metadata {
+	sum := sha256.Sum256(t.Data)
+	meta := cache.Metadata{
+		ContentType: http.DetectContentType(t.Data),
+		Size:        int64(len(t.Data)),
+		SHA256:      hex.EncodeToString(sum[:]),
+		FetchedAt:   fetchedAt.Unix(),
+		SourceURL:   t.URL,
+		Variant:     t.Variant,
+	}
+	if img, err := imaging.Decode(t.Data); err == nil {
+		meta.Width = img.Bounds().Dx()
+		meta.Height = img.Bounds().Dy()
+		meta.BlurHash = imaging.BlurHash(img)
+		meta.DominantColor = imaging.DominantColor(img)
+		meta.PHash = formatPHash(imaging.DHash(img))
+	}
+	return meta
+}
+
+// withPlaceholders computes placeholders of entries cached before they were stored
+func withPlaceholders(data []byte, meta cache.Metadata) cache.Metadata {
+	if meta.BlurHash != "" {
+		return meta
+	}
+	if img, err := imaging.Decode(data); err == nil {
+		meta.BlurHash = imaging.BlurHash(img)
+		meta.DominantColor = imaging.DominantColor(img)
+	}
+	return meta
+}
+
+// backfillMetadata completes metadata of entries cached before it was stored
+// and writes it back, so it is computed once per entry. Variant is known
+// only from the key of such entries.
+func (s *server) backfillMetadata(
+	ctx context.Context,
+	logger *slog.Logger,
+	key string,
+	variant string,
+	data []byte,
+	meta cache.Metadata,
+) cache.Metadata {
+	switch {
+	case meta.SHA256 == "":
+		meta = newMetadata(&downloader.Thumbnail{Data: data, Variant: variant}, time.Unix(meta.FetchedAt, 0))
+	case meta.BlurHash == "":
+		meta = withPlaceholders(data, meta)
+		if meta.BlurHash == "" {
+			// Not an image, nothing to store
+			return meta
+		}
+	default:
+		return meta
+	}
+
+	// The entry keeps its age, failure only means computing it again
+	if err := s.cache.Set(ctx, key, data, meta); err != nil {
+		logger.Warn("Cache SET: could not backfill metadata", slog.String("key", key), slog.Any("err", err))
+	}
+	return meta
+}
+
+func formatPHash(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
+
+func newResponse(
+	req *pb.GetRequest,
+	videoID string,
+	data []byte,
+	meta cache.Metadata,
+	cacheStatus pb.CacheStatus,
+) *pb.GetResponse {
+	res := &pb.GetResponse{
+		Url:         req.Url,
+		VideoId:     videoID,
+		Data:        data,
+		CacheStatus: cacheStatus,
+		Age:         max(time.Now().Unix()-meta.FetchedAt, 0),
+		Width:       int32(meta.Width),
+		Height:      int32(meta.Height),
+		ContentType: meta.ContentType,
+		ByteSize:    meta.Size,
+		Sha256:      meta.SHA256,
+		FetchedAt:   meta.FetchedAt,
+		SourceUrl:   meta.SourceURL,
+		Variant:     meta.Variant,
+
+		Blurhash:      meta.BlurHash,
+		DominantColor: meta.DominantColor,
+	}
+	if meta.Crop != nil {
+		res.Crop = &pb.CropBox{
+			X:      int32(meta.Crop.X),
+			Y:      int32(meta.Crop.Y),
+			Width:  int32(meta.Crop.Width),
+			Height: int32(meta.Crop.Height),
+		}
+	}
+	return res
+}
@@ -0,0 +1,196 @@
+package server
+
+import (
+	"context"
+	"image"
+	"log/slog"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pegov/yt-thumbnails-go/api/thumbnail_v1"
+	"github.com/pegov/yt-thumbnails-go/internal/accesslog"
+	"github.com/pegov/yt-thumbnails-go/internal/imaging"
+)
+
+const (
+	maxMosaicTiles     = 100
+	maxMosaicDimension = 4096
+	// Each mosaic holds a canvas of up to 64 MB and all its tiles in memory
+	maxParallelMosaics = 2
+	defaultTileWidth   = 320
+	defaultTileHeight  = 180
+)
+
+func (s *server) Mosaic(ctx context.Context, req *pb.MosaicRequest) (*pb.MosaicResponse, error) {
+	logger := s.requestLogger(ctx)
+
+	opts, err := newMosaicOptions(req)
+	if err != nil {
+		return nil, err
+	}
+	// Tiles are resized thumbnails, so they obey the same allowlist as Get
+	if !s.isAllowedSize(opts.TileWidth, opts.TileHeight) {
+		return nil, status.Error(codes.InvalidArgument, "tile_width: size is not allowed")
+	}
+	// Only format of the mosaic itself
+	tr, err := newTransform(&pb.GetRequest{Format: req.Format}, false)
+	if err != nil {
+		return nil, err
+	}
+	for i, url := range req.Urls {
+		if _, err := s.extractor.ExtractVideoIDFromURL(url); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "urls[%d]: invalid url", i)
+		}
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, time.Duration(s.requestTimeout.Load()))
+	err = s.mosaics.Acquire(waitCtx)
+	cancel()
+	if err != nil {
+		logger.Warn("Mosaic: waiting for semaphore", slog.Any("err", err))
+		return nil, status.FromContextError(err).Err()
+	}
+	defer s.mosaics.Release()
+
+	// Tiles go through Get, so resized thumbnails are cached and downloads
+	// and resizing share the limits. Access log keeps the mosaic RPC.
+	tileCtx := accesslog.Detach(ctx)
+	responses := make([]*pb.GetResponse, len(req.Urls))
+	errs := make([]error, len(req.Urls))
+	var wg sync.WaitGroup
+	for i, url := range req.Urls {
+		wg.Add(1)
+		go func(i int, url string) {
+			defer wg.Done()
+			responses[i], errs[i] = s.Get(tileCtx, &pb.GetRequest{
+				Url:    url,
+				Width:  int32(opts.TileWidth),
+				Height: int32(opts.TileHeight),
+				Fit:    pb.Fit_FIT_COVER,
+			})
+		}(i, url)
+	}
+	wg.Wait()
+
+	res := &pb.MosaicResponse{Missing: []string{}}
+	tiles := make([]image.Image, len(req.Urls))
+	captions := make([]string, len(req.Urls))
+	for i, url := range req.Urls {
+		switch status.Code(errs[i]) {
+		case codes.OK:
+		case codes.NotFound, codes.Unavailable:
+			// Mosaic is still useful without some thumbnails
+			res.Missing = append(res.Missing, url)
+			continue
+		default:
+			return nil, errs[i]
+		}
+
+		tiles[i], err = imaging.Decode(responses[i].Data)
+		if err != nil {
+			logger.Error(
+				"Mosaic: internal error",
+				slog.String("video_id", responses[i].VideoId),
+				slog.Any("err", err),
+			)
+			return nil, errInternal
+		}
+		if req.Captions {
+			captions[i] = responses[i].VideoId
+		}
+	}
+
+	_, span := tracer.Start(ctx, "transforms.Wait")
+	err = s.transforms.Acquire(ctx)
+	span.End()
+	if err != nil {
+		logger.Warn("Mosaic: waiting for semaphore", slog.Any("err", err))
+		return nil, status.FromContextError(err).Err()
+	}
+	defer s.transforms.Release()
+
+	_, span = tracer.Start(ctx, "imaging.Mosaic")
+	img := imaging.Mosaic(tiles, captions, opts)
+	encOpts := imaging.Options{Quality: tr.quality, Progressive: tr.progressive}
+	if tr.encoding != nil {
+		encOpts.Encoding = *tr.encoding
+	}
+	data, err := imaging.Encode(img, encOpts)
+	span.End()
+	if err != nil {
+		logger.Error("Mosaic: internal error", slog.Any("err", err))
+		return nil, errInternal
+	}
+
+	res.Data = data
+	res.ContentType = http.DetectContentType(data)
+	res.Width = int32(img.Bounds().Dx())
+	res.Height = int32(img.Bounds().Dy())
+	return res, nil
+}
+
+// newMosaicOptions validates grid of the request and fills in defaults
+func newMosaicOptions(req *pb.MosaicRequest) (imaging.MosaicOptions, error) {
+	n := len(req.Urls)
+	if n == 0 {
+		return imaging.MosaicOptions{}, status.Error(codes.InvalidArgument, "urls: required")
+	}
+	if n > maxMosaicTiles {
+		return imaging.MosaicOptions{}, status.Errorf(codes.InvalidArgument, "urls: at most %d", maxMosaicTiles)
+	}
+	if req.Columns < 0 || req.Rows < 0 {
+		return imaging.MosaicOptions{}, status.Error(codes.InvalidArgument, "columns and rows: must not be negative")
+	}
+	if req.TileWidth < 0 || req.TileWidth > imaging.MaxDimension {
+		return imaging.MosaicOptions{}, status.Errorf(codes.InvalidArgument, "tile_width: must be between 0 and %d", imaging.MaxDimension)
+	}
+	if req.TileHeight < 0 || req.TileHeight > imaging.MaxDimension {
+		return imaging.MosaicOptions{}, status.Errorf(codes.InvalidArgument, "tile_height: must be between 0 and %d", imaging.MaxDimension)
+	}
+	if req.Padding < 0 || req.Padding > maxMosaicDimension {
+		return imaging.MosaicOptions{}, status.Errorf(codes.InvalidArgument, "padding: must be between 0 and %d", maxMosaicDimension)
+	}
+
+	opts := imaging.MosaicOptions{
+		Columns:    int(req.Columns),
+		Rows:       int(req.Rows),
+		TileWidth:  int(req.TileWidth),
+		TileHeight: int(req.TileHeight),
+		Padding:    int(req.Padding),
+	}
+	if opts.TileWidth == 0 {
+		opts.TileWidth = defaultTileWidth
+	}
+	if opts.TileHeight == 0 {
+		opts.TileHeight = defaultTileHeight
+	}
+	switch {
+	case opts.Columns == 0 && opts.Rows == 0:
+		opts.Columns = int(math.Ceil(math.Sqrt(float64(n))))
+		opts.Rows = (n + opts.Columns - 1) / opts.Columns
+	case opts.Columns == 0:
+		opts.Columns = (n + opts.Rows - 1) / opts.Rows
+	case opts.Rows == 0:
+		opts.Rows = (n + opts.Columns - 1) / opts.Columns
+	}
+	// Checked first, so the products below can't overflow
+	if opts.Columns > maxMosaicTiles || opts.Rows > maxMosaicTiles {
+		return imaging.MosaicOptions{}, status.Errorf(codes.InvalidArgument, "columns and rows: at most %d", maxMosaicTiles)
+	}
+	if opts.Columns*opts.Rows < n {
+		return imaging.MosaicOptions{}, status.Error(codes.InvalidArgument, "columns and rows: grid must fit all urls")
+	}
+
+	width := opts.Columns*opts.TileWidth + (opts.Columns+1)*opts.Padding
+	height := opts.Rows*opts.TileHeight + (opts.Rows+1)*opts.Padding
+	if width > maxMosaicDimension || height > maxMosaicDimension {
+		return imaging.MosaicOptions{}, status.Errorf(codes.InvalidArgument,
+			"columns and rows: mosaic of %dx%d is larger than %d", width, height, maxMosaicDimension)
+	}
+	return opts, nil
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pegov/yt-thumbnails-go/api/thumbnail_v1"
+	"github.com/pegov/yt-thumbnails-go/internal/downloader"
+	"github.com/pegov/yt-thumbnails-go/internal/imaging"
+)
+
+// missingDownloader has no thumbnails of one video
+type missingDownloader struct {
+	fakeDownloader
+	videoID string
+}
+
+func (d *missingDownloader) DownloadThumbnail(ctx context.Context, videoID string) (*downloader.Thumbnail, error) {
+	if videoID == d.videoID {
+		return nil, downloader.ErrNotFound
+	}
+	return d.thumbnail, nil
+}
+
+func TestMosaic(t *testing.T) {
+	ctx := context.Background()
+	d := &missingDownloader{
+		fakeDownloader: fakeDownloader{thumbnail: &downloader.Thumbnail{Data: hq, Variant: downloader.VariantHq}},
+		videoID:        "aaaaaaaaaaa",
+	}
+	svc, c := newTestServer(t, d)
+
+	r, err := svc.Mosaic(ctx, &pb.MosaicRequest{
+		Urls:     []string{"jNQXAC9IVRw", "aaaaaaaaaaa", "https://youtu.be/dQw4w9WgXcQ"},
+		Padding:  4,
+		Captions: true,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "image/jpeg", r.ContentType)
+	// 2x2 grid of default tiles
+	assert.Equal(t, int32(2*320+3*4), r.Width)
+	assert.Equal(t, int32(2*180+3*4), r.Height)
+	assert.Equal(t, []string{"aaaaaaaaaaa"}, r.Missing)
+	img, err := imaging.Decode(r.Data)
+	assert.Nil(t, err)
+	assert.Equal(t, image.Rect(0, 0, int(r.Width), int(r.Height)), img.Bounds())
+
+	// Tiles are cached resized
+	_, _, err = c.Get(ctx, "jNQXAC9IVRw/320x180-cover", 0)
+	assert.Nil(t, err)
+
+	r, err = svc.Mosaic(ctx, &pb.MosaicRequest{
+		Urls:    []string{"jNQXAC9IVRw"},
+		Columns: 3,
+		Format:  &pb.Format{Encoding: pb.Encoding_ENCODING_PNG},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "image/png", r.ContentType)
+	assert.Equal(t, int32(3*320), r.Width)
+
+	for _, req := range []*pb.MosaicRequest{
+		{},
+		{Urls: []string{"invalid url"}},
+		{Urls: []string{"jNQXAC9IVRw", "dQw4w9WgXcQ"}, Columns: 1, Rows: 1},
+		{Urls: []string{"jNQXAC9IVRw"}, TileWidth: imaging.MaxDimension, Columns: 4},
+		{Urls: []string{"jNQXAC9IVRw"}, Format: &pb.Format{Encoding: pb.Encoding_ENCODING_PNG, Quality: 80}},
+	} {
+		_, err = svc.Mosaic(ctx, req)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+
+	// Tile size must be allowed, default one too
+	svc.SetAllowedSizes([]image.Point{image.Pt(160, 90)})
+	r, err = svc.Mosaic(ctx, &pb.MosaicRequest{Urls: []string{"jNQXAC9IVRw"}, TileWidth: 160, TileHeight: 90})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(160), r.Width)
+	_, err = svc.Mosaic(ctx, &pb.MosaicRequest{Urls: []string{"jNQXAC9IVRw"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.ErrorContains(t, err, "tile_width: size is not allowed")
+}
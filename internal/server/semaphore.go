@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"sync"
+)
+
+// semaphore limits parallel upstream requests. Unlike a buffered channel
+// its limit can be changed while requests are in flight.
+type semaphore struct {
+	mu      sync.Mutex
+	limit   int
+	used    int
+	waiters []chan struct{}
+}
+
+func newSemaphore(limit int) *semaphore {
+	return &semaphore{limit: limit}
+}
+
+// Acquire blocks until a slot is free or ctx is done
+func (s *semaphore) Acquire(ctx context.Context) error {
+	s.mu.Lock()
+	if s.used < s.limit && len(s.waiters) == 0 {
+		s.used++
+		s.mu.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	s.waiters = append(s.waiters, ready)
+	s.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		for i, w := range s.waiters {
+			if w == ready {
+				s.waiters = append(s.waiters[:i], s.waiters[i+1:]...)
+				s.mu.Unlock()
+				return ctx.Err()
+			}
+		}
+		s.mu.Unlock()
+		// Slot was granted at the same time
+		s.Release()
+		return ctx.Err()
+	}
+}
+
+func (s *semaphore) Release() {
+	s.mu.Lock()
+	s.used--
+	s.notify()
+	s.mu.Unlock()
+}
+
+// SetLimit changes the limit. Requests in flight above new limit
+// are not interrupted, new ones wait until they finish.
+func (s *semaphore) SetLimit(limit int) {
+	s.mu.Lock()
+	s.limit = limit
+	s.notify()
+	s.mu.Unlock()
+}
+
+func (s *semaphore) notify() {
+	for s.used < s.limit && len(s.waiters) > 0 {
+		close(s.waiters[0])
+		s.waiters = s.waiters[1:]
+		s.used++
+	}
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSemaphoreTimeout(t *testing.T) {
+	s := newSemaphore(1)
+	assert.Nil(t, s.Acquire(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, s.Acquire(ctx), context.DeadlineExceeded)
+
+	s.Release()
+	assert.Nil(t, s.Acquire(context.Background()))
+}
+
+func TestSemaphoreSetLimit(t *testing.T) {
+	s := newSemaphore(1)
+	assert.Nil(t, s.Acquire(context.Background()))
+
+	acquired := make(chan struct{})
+	go func() {
+		s.Acquire(context.Background())
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("acquired above the limit")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	s.SetLimit(2)
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("not acquired after limit increase")
+	}
+
+	// Shrinking below used slots blocks new requests
+	s.SetLimit(1)
+	s.Release()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, s.Acquire(ctx), context.DeadlineExceeded)
+	s.Release()
+	assert.Nil(t, s.Acquire(context.Background()))
+}
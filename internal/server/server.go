@@ -2,19 +2,34 @@ package server
 
 import (
 	"context"
+	"errors"
+	"image"
 	"log/slog"
+	"runtime"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	pb "github.com/pegov/yt-thumbnails-go/api/thumbnail_v1"
+	"github.com/pegov/yt-thumbnails-go/internal/auth"
+	"github.com/pegov/yt-thumbnails-go/internal/cache"
+	"github.com/pegov/yt-thumbnails-go/internal/downloader"
+	"github.com/pegov/yt-thumbnails-go/internal/logging"
 )
 
 type Cache interface {
-	Get(ctx context.Context, videoID string) ([]byte, error)
-	Set(ctx context.Context, videoID string, data []byte, ts int64) error
+	// Get returns data and its metadata, maxAge 0 means cache TTL
+	Get(ctx context.Context, videoID string, maxAge time.Duration) ([]byte, cache.Metadata, error)
+	Set(ctx context.Context, videoID string, data []byte, meta cache.Metadata) error
+	// FindSimilar searches entries under plain video IDs and given variants
+	FindSimilar(ctx context.Context, hash uint64, maxDistance int, limit int, variants []string) ([]cache.Similar, error)
 }
 
 type Downloader interface {
-	DownloadThumbnail(ctx context.Context, videoID string) ([]byte, error)
+	DownloadThumbnail(ctx context.Context, videoID string) (*downloader.Thumbnail, error)
+	DownloadVariant(ctx context.Context, videoID string, variant string) (*downloader.Thumbnail, error)
+	DownloadFrame(ctx context.Context, videoID string, index int) (*downloader.Thumbnail, error)
+	HeadVariant(ctx context.Context, videoID string, variant string) (*downloader.Head, error)
 }
 
 type Extractor interface {
@@ -28,10 +43,19 @@ type server struct {
 	cache      Cache
 	extractor  Extractor
 	downloader Downloader
-	semaphore  chan struct{}
-	shutdown   chan<- struct{}
-	mu         sync.Mutex
-	isStopping bool
+	// timeout for cache and upstream requests of one call
+	requestTimeout atomic.Int64
+	// cropLetterbox is default of GetRequest.crop_letterbox
+	cropLetterbox atomic.Bool
+	semaphore     *semaphore
+	// transforms limits CPU-bound image processing
+	transforms *semaphore
+	mosaics    *semaphore
+	// allowedSizes of resized images, nil means any
+	allowedSizes atomic.Pointer[map[image.Point]bool]
+	shutdown     chan<- struct{}
+	mu           sync.Mutex
+	isStopping   bool
 }
 
 func NewServer(
@@ -40,21 +64,69 @@ func NewServer(
 	extractor Extractor,
 	downloader Downloader,
 	maxParallelHTTPRequests int,
+	requestTimeout time.Duration,
 	shutdown chan<- struct{},
 ) *server {
-	return &server{
+	s := &server{
 		logger:     logger,
 		cache:      cache,
 		extractor:  extractor,
 		downloader: downloader,
-		semaphore:  make(chan struct{}, maxParallelHTTPRequests),
+		semaphore:  newSemaphore(maxParallelHTTPRequests),
+		transforms: newSemaphore(runtime.NumCPU()),
+		mosaics:    newSemaphore(maxParallelMosaics),
 		shutdown:   shutdown,
 		mu:         sync.Mutex{},
 		isStopping: false,
 	}
+	s.requestTimeout.Store(int64(requestTimeout))
+	return s
+}
+
+// SetMaxParallelHTTPRequests resizes upstream request limit at runtime
+func (s *server) SetMaxParallelHTTPRequests(n int) {
+	s.semaphore.SetLimit(n)
+}
+
+// SetRequestTimeout changes timeout for calls started after it
+func (s *server) SetRequestTimeout(timeout time.Duration) {
+	s.requestTimeout.Store(int64(timeout))
+}
+
+// SetMaxParallelTransforms resizes image processing limit at runtime
+func (s *server) SetMaxParallelTransforms(n int) {
+	s.transforms.SetLimit(n)
+}
+
+// SetAllowedSizes restricts width and height of resized images,
+// so every size gets its own cache entry. Empty allows any size.
+func (s *server) SetAllowedSizes(sizes []image.Point) {
+	if len(sizes) == 0 {
+		s.allowedSizes.Store(nil)
+		return
+	}
+	allowed := make(map[image.Point]bool, len(sizes))
+	for _, size := range sizes {
+		allowed[size] = true
+	}
+	s.allowedSizes.Store(&allowed)
+}
+
+func (s *server) isAllowedSize(width int, height int) bool {
+	allowed := s.allowedSizes.Load()
+	return allowed == nil || (*allowed)[image.Pt(width, height)]
+}
+
+// SetCropLetterbox changes default of letterbox cropping
+func (s *server) SetCropLetterbox(crop bool) {
+	s.cropLetterbox.Store(crop)
 }
 
 func (s *server) stopOnInternalError(err error) {
+	// Request timed out or was canceled, the cache is fine
+	if isContextError(err) {
+		return
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if !s.isStopping {
@@ -62,3 +134,16 @@ func (s *server) stopOnInternalError(err error) {
 		s.isStopping = true
 	}
 }
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// requestLogger returns the request scoped logger with the API key ID.
+func (s *server) requestLogger(ctx context.Context) *slog.Logger {
+	logger := logging.FromContext(ctx, s.logger)
+	if keyID, ok := auth.KeyIDFromContext(ctx); ok {
+		logger = logger.With(slog.String("key_id", keyID))
+	}
+	return logger
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pegov/yt-thumbnails-go/api/thumbnail_v1"
+	"github.com/pegov/yt-thumbnails-go/internal/accesslog"
+	"github.com/pegov/yt-thumbnails-go/internal/cache"
+	"github.com/pegov/yt-thumbnails-go/internal/downloader"
+	"github.com/pegov/yt-thumbnails-go/internal/imaging"
+)
+
+const (
+	defaultSimilarDistance = 6
+	defaultSimilarLimit    = 20
+	maxSimilarLimit        = 1000
+)
+
+func (s *server) FindSimilar(ctx context.Context, req *pb.FindSimilarRequest) (*pb.FindSimilarResponse, error) {
+	logger := s.requestLogger(ctx)
+
+	maxDistance := defaultSimilarDistance
+	if req.MaxDistance != nil {
+		maxDistance = int(*req.MaxDistance)
+	}
+	if maxDistance < 0 || maxDistance > cache.MaxDistance {
+		return nil, status.Errorf(codes.InvalidArgument, "max_distance: must be between 0 and %d", cache.MaxDistance)
+	}
+	limit := int(req.Limit)
+	if limit < 0 || limit > maxSimilarLimit {
+		return nil, status.Errorf(codes.InvalidArgument, "limit: must be between 0 and %d", maxSimilarLimit)
+	}
+	if limit == 0 {
+		limit = defaultSimilarLimit
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(s.requestTimeout.Load()))
+	defer cancel()
+
+	var (
+		hash    uint64
+		videoID string
+	)
+	switch query := req.Query.(type) {
+	case *pb.FindSimilarRequest_Url:
+		var err error
+		videoID, err = s.extractor.ExtractVideoIDFromURL(query.Url)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "url: invalid url")
+		}
+		accesslog.SetVideoID(ctx, videoID)
+
+		// Same as Get of the best variant, so the query video gets cached
+		getReq := &pb.GetRequest{Url: query.Url}
+		data, meta, cacheStatus, err := s.load(ctx, logger, videoID, videoID, getReq, func(ctx context.Context) ([]byte, cache.Metadata, error) {
+			return s.download(ctx, logger, videoID, getReq)
+		})
+		setCacheOutcome(ctx, cacheStatus)
+		if err != nil {
+			return nil, err
+		}
+		if hash, err = strconv.ParseUint(meta.PHash, 16, 64); err != nil {
+			// Entry cached before hashes were stored
+			img, err := imaging.Decode(data)
+			if err != nil {
+				logger.Error("FindSimilar: internal error", slog.String("video_id", videoID), slog.Any("err", err))
+				return nil, errInternal
+			}
+			hash = imaging.DHash(img)
+		}
+	case *pb.FindSimilarRequest_Image:
+		var err error
+		if hash, err = s.hashImage(ctx, logger, query.Image); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, status.Error(codes.InvalidArgument, "url or image: required")
+	}
+
+	// One more in case the queried video itself is found
+	similar, err := s.cache.FindSimilar(ctx, hash, maxDistance, limit+1, downloader.Variants)
+	if err != nil {
+		return nil, s.cacheError(logger, "FindSimilar", videoID, err)
+	}
+
+	res := &pb.FindSimilarResponse{Phash: formatPHash(hash), Videos: []*pb.SimilarVideo{}}
+	for _, v := range similar {
+		if v.VideoID == videoID || len(res.Videos) == limit {
+			continue
+		}
+		res.Videos = append(res.Videos, &pb.SimilarVideo{VideoId: v.VideoID, Distance: int32(v.Distance)})
+	}
+	return res, nil
+}
+
+// hashImage computes difference hash of uploaded image
+func (s *server) hashImage(ctx context.Context, logger *slog.Logger, data []byte) (uint64, error) {
+	_, span := tracer.Start(ctx, "transforms.Wait")
+	err := s.transforms.Acquire(ctx)
+	span.End()
+	if err != nil {
+		logger.Warn("FindSimilar: waiting for semaphore", slog.Any("err", err))
+		return 0, status.FromContextError(err).Err()
+	}
+	defer s.transforms.Release()
+
+	img, err := imaging.DecodeLimited(data)
+	switch {
+	case errors.Is(err, imaging.ErrTooLarge):
+		return 0, status.Errorf(codes.InvalidArgument, "image: must be at most %dx%d", imaging.MaxDimension, imaging.MaxDimension)
+	case err != nil:
+		return 0, status.Error(codes.InvalidArgument, "image: could not decode image")
+	}
+	return imaging.DHash(img), nil
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pegov/yt-thumbnails-go/api/thumbnail_v1"
+	"github.com/pegov/yt-thumbnails-go/internal/downloader"
+	"github.com/pegov/yt-thumbnails-go/internal/imaging"
+)
+
+func TestFindSimilar(t *testing.T) {
+	ctx := context.Background()
+	d := &fakeDownloader{thumbnail: &downloader.Thumbnail{Data: hq, Variant: downloader.VariantHq}}
+	svc, _ := newTestServer(t, d)
+
+	// Reupload of the same thumbnail and a different one
+	_, err := svc.Get(ctx, &pb.GetRequest{Url: "jNQXAC9IVRw"})
+	assert.Nil(t, err)
+	_, err = svc.Get(ctx, &pb.GetRequest{Url: "jNQXAC9IVRw", Width: 100})
+	assert.Nil(t, err)
+	_, err = svc.Get(ctx, &pb.GetRequest{Url: "aaaaaaaaaaa"})
+	assert.Nil(t, err)
+	d.thumbnail = &downloader.Thumbnail{Data: wantBytes, Variant: downloader.VariantMaxRes}
+	_, err = svc.Get(ctx, &pb.GetRequest{Url: "dQw4w9WgXcQ"})
+	assert.Nil(t, err)
+
+	r, err := svc.FindSimilar(ctx, &pb.FindSimilarRequest{Query: &pb.FindSimilarRequest_Url{Url: "jNQXAC9IVRw"}})
+	assert.Nil(t, err)
+	assert.Len(t, r.Phash, 16)
+	assert.Len(t, r.Videos, 1)
+	assert.Equal(t, "aaaaaaaaaaa", r.Videos[0].VideoId)
+	assert.Equal(t, int32(0), r.Videos[0].Distance)
+
+	small, _ := imaging.Decode(hq)
+	b, _ := imaging.Encode(imaging.Resize(small, 120, 0, imaging.FitContain), imaging.Options{Quality: 60})
+	r, err = svc.FindSimilar(ctx, &pb.FindSimilarRequest{Query: &pb.FindSimilarRequest_Image{Image: b}})
+	assert.Nil(t, err)
+	assert.Len(t, r.Videos, 2)
+	// Exact matches only
+	r, err = svc.FindSimilar(ctx, &pb.FindSimilarRequest{Query: &pb.FindSimilarRequest_Url{Url: "jNQXAC9IVRw"}, MaxDistance: ptr(int32(0))})
+	assert.Nil(t, err)
+	assert.Len(t, r.Videos, 1)
+
+	_, err = svc.FindSimilar(ctx, &pb.FindSimilarRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = svc.FindSimilar(ctx, &pb.FindSimilarRequest{Query: &pb.FindSimilarRequest_Image{Image: []byte("x")}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = svc.FindSimilar(ctx, &pb.FindSimilarRequest{Query: &pb.FindSimilarRequest_Url{Url: "jNQXAC9IVRw"}, MaxDistance: ptr(int32(12))})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	// Header only, pixels are never allocated
+	_, err = svc.FindSimilar(ctx, &pb.FindSimilarRequest{Query: &pb.FindSimilarRequest_Image{Image: pngHeader(65535, 65535)}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.ErrorContains(t, err, "image: must be at most")
+}
+
+// pngHeader returns PNG signature and IHDR chunk of width x height RGBA image
+func pngHeader(width uint32, height uint32) []byte {
+	ihdr := []byte("IHDR")
+	ihdr = binary.BigEndian.AppendUint32(ihdr, width)
+	ihdr = binary.BigEndian.AppendUint32(ihdr, height)
+	ihdr = append(ihdr, 8, 6, 0, 0, 0)
+
+	b := []byte("\x89PNG\r\n\x1a\n")
+	b = binary.BigEndian.AppendUint32(b, uint32(len(ihdr)-4))
+	b = append(b, ihdr...)
+	return binary.BigEndian.AppendUint32(b, crc32.ChecksumIEEE(ihdr))
+}
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"fmt"
+	"image"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pegov/yt-thumbnails-go/api/thumbnail_v1"
+	"github.com/pegov/yt-thumbnails-go/internal/imaging"
+)
+
+// transform is image processing requested in GetRequest.
+// Transformed images are cached under derived keys.
+type transform struct {
+	width         int
+	height        int
+	fit           imaging.Fit
+	cropLetterbox bool
+	// encoding is nil to keep the original one
+	encoding      *imaging.Encoding
+	quality       int
+	progressive   bool
+	stripMetadata bool
+}
+
+func newTransform(req *pb.GetRequest, cropLetterbox bool) (transform, error) {
+	if req.Width < 0 || req.Width > imaging.MaxDimension {
+		return transform{}, status.Errorf(codes.InvalidArgument, "width: must be between 0 and %d", imaging.MaxDimension)
+	}
+	if req.Height < 0 || req.Height > imaging.MaxDimension {
+		return transform{}, status.Errorf(codes.InvalidArgument, "height: must be between 0 and %d", imaging.MaxDimension)
+	}
+
+	t := transform{width: int(req.Width), height: int(req.Height), cropLetterbox: cropLetterbox}
+	if req.CropLetterbox != nil {
+		t.cropLetterbox = *req.CropLetterbox
+	}
+	switch req.Fit {
+	case pb.Fit_FIT_UNSPECIFIED, pb.Fit_FIT_CONTAIN:
+		t.fit = imaging.FitContain
+	case pb.Fit_FIT_COVER:
+		t.fit = imaging.FitCover
+	case pb.Fit_FIT_FILL:
+		t.fit = imaging.FitFill
+	default:
+		return transform{}, status.Error(codes.InvalidArgument, "fit: invalid fit")
+	}
+	if t.width == 0 || t.height == 0 {
+		// Aspect ratio is kept, fit doesn't matter
+		t.fit = imaging.FitContain
+	}
+
+	format := req.GetFormat()
+	switch format.GetEncoding() {
+	case pb.Encoding_ENCODING_UNSPECIFIED:
+	case pb.Encoding_ENCODING_JPEG:
+		t.encoding = ptr(imaging.EncodingJPEG)
+	case pb.Encoding_ENCODING_PNG:
+		t.encoding = ptr(imaging.EncodingPNG)
+	default:
+		return transform{}, status.Error(codes.InvalidArgument, "format.encoding: invalid encoding")
+	}
+	if format.GetQuality() < 0 || format.GetQuality() > 100 {
+		return transform{}, status.Error(codes.InvalidArgument, "format.quality: must be between 0 and 100")
+	}
+	t.quality = int(format.GetQuality())
+	t.progressive = format.GetProgressive()
+	t.stripMetadata = format.GetStripMetadata()
+	if t.encoding != nil && *t.encoding == imaging.EncodingPNG && (t.quality != 0 || t.progressive) {
+		return transform{}, status.Error(codes.InvalidArgument, "format: quality and progressive are only for JPEG")
+	}
+	return t, nil
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func (t transform) isZero() bool {
+	return t.width == 0 && t.height == 0 && !t.cropLetterbox && !t.hasFormat()
+}
+
+func (t transform) hasFormat() bool {
+	return t.encoding != nil || t.quality != 0 || t.progressive || t.stripMetadata
+}
+
+// key is appended to the cache key of the original image,
+// so invalidation of a video removes transformed images too
+func (t transform) key() string {
+	var parts []string
+	if t.cropLetterbox {
+		parts = append(parts, "letterbox")
+	}
+	if t.width != 0 || t.height != 0 {
+		parts = append(parts, fmt.Sprintf("%dx%d-%s", t.width, t.height, t.fit))
+	}
+	if t.hasFormat() {
+		var format []string
+		if t.encoding != nil {
+			format = append(format, t.encoding.String())
+		}
+		if t.quality != 0 {
+			format = append(format, fmt.Sprintf("q%d", t.quality))
+		}
+		if t.progressive {
+			format = append(format, "progressive")
+		}
+		if t.stripMetadata {
+			format = append(format, "strip")
+		}
+		parts = append(parts, strings.Join(format, "-"))
+	}
+	return strings.Join(parts, ",")
+}
+
+// reencode reports whether data has to be encoded again
+// even if the image itself is not changed
+func (t transform) reencode(data []byte) bool {
+	if t.quality != 0 || t.progressive {
+		return true
+	}
+	if t.encoding == nil {
+		return false
+	}
+	contentType := http.DetectContentType(data)
+	return (*t.encoding == imaging.EncodingJPEG && contentType != "image/jpeg") ||
+		(*t.encoding == imaging.EncodingPNG && contentType != "image/png")
+}
+
+// apply returns processed image and the kept area of the source if it was
+// cropped, data is returned as is if nothing changed
+func (t transform) apply(data []byte) ([]byte, image.Rectangle, error) {
+	var crop image.Rectangle
+	reencode := t.reencode(data)
+	if t.width == 0 && t.height == 0 && !t.cropLetterbox && !reencode {
+		return t.strip(data), crop, nil
+	}
+
+	img, err := imaging.Decode(data)
+	if err != nil {
+		return nil, crop, err
+	}
+
+	if t.cropLetterbox {
+		if r := imaging.DetectLetterbox(img); r != img.Bounds() {
+			crop = r
+			img = imaging.Crop(img, r)
+		}
+	}
+	out := imaging.Resize(img, t.width, t.height, t.fit)
+	if out == img && crop.Empty() && !reencode {
+		return t.strip(data), crop, nil
+	}
+
+	// Encoded images have no metadata
+	opts := imaging.Options{Quality: t.quality, Progressive: t.progressive}
+	if t.encoding != nil {
+		opts.Encoding = *t.encoding
+	}
+	b, err := imaging.Encode(out, opts)
+	return b, crop, err
+}
+
+// strip removes metadata of unchanged image if requested,
+// only JPEG can have it
+func (t transform) strip(data []byte) []byte {
+	if !t.stripMetadata {
+		return data
+	}
+	if b, err := imaging.StripMetadata(data); err == nil {
+		return b
+	}
+	return data
+}
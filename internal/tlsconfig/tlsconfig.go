@@ -0,0 +1,208 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNoCertificate  = errors.New("no certificate configured")
+	ErrNoCertificates = errors.New("no certificates found in CA file")
+	ErrNoPeerCerts    = errors.New("peer did not present a certificate")
+	ErrNoServerName   = errors.New("no server name to verify")
+)
+
+// Reloader holds certificate, key and CA pool loaded from files
+// and swaps them when Reload is called or the files change.
+// Any of the files may be empty.
+type Reloader struct {
+	logger   *slog.Logger
+	certFile string
+	keyFile  string
+	caFile   string
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	pool     *x509.CertPool
+	modTimes map[string]time.Time
+}
+
+func NewReloader(logger *slog.Logger, certFile, keyFile, caFile string) (*Reloader, error) {
+	r := &Reloader{
+		logger:   logger,
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload reads all files again. On error previous certificates stay in use.
+func (r *Reloader) Reload() error {
+	var cert *tls.Certificate
+	if r.certFile != "" || r.keyFile != "" {
+		c, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+		if err != nil {
+			return err
+		}
+		cert = &c
+	}
+
+	var pool *x509.CertPool
+	if r.caFile != "" {
+		b, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return err
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return ErrNoCertificates
+		}
+	}
+
+	r.mu.Lock()
+	r.cert = cert
+	r.pool = pool
+	r.modTimes = r.readModTimes()
+	r.mu.Unlock()
+
+	return nil
+}
+
+// Watch reloads certificates every time one of the files is modified
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+			if err := r.Reload(); err != nil {
+				r.logger.Error("Could not reload certificates", slog.Any("err", err))
+				continue
+			}
+			r.logger.Info("Certificates reloaded")
+		}
+	}
+}
+
+func (r *Reloader) changed() bool {
+	modTimes := r.readModTimes()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for name, t := range modTimes {
+		if !t.Equal(r.modTimes[name]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reloader) readModTimes() map[string]time.Time {
+	modTimes := make(map[string]time.Time, 3)
+	for _, name := range []string{r.certFile, r.keyFile, r.caFile} {
+		if name == "" {
+			continue
+		}
+		if info, err := os.Stat(name); err == nil {
+			modTimes[name] = info.ModTime()
+		}
+	}
+	return modTimes
+}
+
+func (r *Reloader) certificate() *tls.Certificate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert
+}
+
+func (r *Reloader) certPool() *x509.CertPool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.pool
+}
+
+// ServerConfig requires and verifies client certificates (mTLS)
+// if CA file is set.
+func (r *Reloader) ServerConfig() *tls.Config {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			if cert := r.certificate(); cert != nil {
+				return cert, nil
+			}
+			return nil, ErrNoCertificate
+		},
+	}
+	if r.caFile != "" {
+		// Default verification is replaced to pick up reloaded CA pool
+		cfg.ClientAuth = tls.RequireAnyClientCert
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			return r.verify(cs.PeerCertificates, "", x509.ExtKeyUsageClientAuth)
+		}
+	}
+	return cfg
+}
+
+// ClientConfig verifies server against CA file (system roots if empty)
+// and presents client certificate if set. Server certificate must be valid
+// for serverName, a host name or IP address. Handshake fails if it is empty.
+func (r *Reloader) ClientConfig(serverName string) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			if cert := r.certificate(); cert != nil {
+				return cert, nil
+			}
+			return &tls.Certificate{}, nil
+		},
+		// Default verification is replaced to pick up reloaded CA pool
+		InsecureSkipVerify: true,
+		// Not cs.ServerName, it is empty for IP addresses
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if serverName == "" {
+				return ErrNoServerName
+			}
+			return r.verify(cs.PeerCertificates, serverName, x509.ExtKeyUsageServerAuth)
+		},
+	}
+}
+
+func (r *Reloader) verify(
+	certs []*x509.Certificate,
+	dnsName string,
+	usage x509.ExtKeyUsage,
+) error {
+	if len(certs) == 0 {
+		return ErrNoPeerCerts
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		DNSName:       dnsName,
+		Roots:         r.certPool(),
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{usage},
+	})
+	return err
+}
@@ -0,0 +1,184 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type keyPair struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// generate creates certificate signed by parent (self-signed if parent is nil)
+func generate(t *testing.T, serial int64, name string, parent *keyPair) *keyPair {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	if ip := net.ParseIP(name); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{name}
+	}
+
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	assert.Nil(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+	return &keyPair{cert, key}
+}
+
+func (p *keyPair) write(t *testing.T, dir string, name string) (string, string) {
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+
+	keyDER, err := x509.MarshalECPrivateKey(p.key)
+	assert.Nil(t, err)
+	assert.Nil(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: p.cert.Raw}), 0600))
+	assert.Nil(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile
+}
+
+// handshake returns serial number of certificate presented by server
+func handshake(serverConfig *tls.Config, clientConfig *tls.Config) (int64, error) {
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+
+	errs := make(chan error, 1)
+	go func() {
+		defer serverConn.Close()
+		conn := tls.Server(serverConn, serverConfig)
+		if err := conn.Handshake(); err != nil {
+			errs <- err
+			return
+		}
+		_, err := conn.Write([]byte{1})
+		errs <- err
+	}()
+
+	client := tls.Client(clientConn, clientConfig)
+	err := client.Handshake()
+	if err == nil {
+		// With TLS 1.3 client certificate is rejected after client handshake
+		_, err = client.Read(make([]byte, 1))
+	}
+	clientConn.Close()
+	if serverErr := <-errs; err == nil {
+		err = serverErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	return client.ConnectionState().PeerCertificates[0].SerialNumber.Int64(), nil
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := generate(t, 1, "ca", nil)
+	caFile, _ := ca.write(t, dir, "ca")
+	serverCert, serverKey := generate(t, 2, "localhost", ca).write(t, dir, "server")
+	clientCert, clientKey := generate(t, 3, "client", ca).write(t, dir, "client")
+
+	server, err := NewReloader(slog.Default(), serverCert, serverKey, caFile)
+	assert.Nil(t, err)
+	client, err := NewReloader(slog.Default(), clientCert, clientKey, caFile)
+	assert.Nil(t, err)
+
+	serial, err := handshake(server.ServerConfig(), client.ClientConfig("localhost"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), serial)
+
+	// Server name override must match certificate
+	_, err = handshake(server.ServerConfig(), client.ClientConfig("example.com"))
+	assert.NotNil(t, err)
+
+	// IP address is verified too, though it is not sent as SNI
+	_, err = handshake(server.ServerConfig(), client.ClientConfig("10.0.0.5"))
+	assert.NotNil(t, err)
+	_, err = handshake(server.ServerConfig(), client.ClientConfig(""))
+	assert.ErrorIs(t, err, ErrNoServerName)
+	ipCert, ipKey := generate(t, 4, "10.0.0.5", ca).write(t, dir, "ip")
+	ipServer, err := NewReloader(slog.Default(), ipCert, ipKey, caFile)
+	assert.Nil(t, err)
+	serial, err = handshake(ipServer.ServerConfig(), client.ClientConfig("10.0.0.5"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), serial)
+
+	// Client without certificate is rejected
+	anonymous, err := NewReloader(slog.Default(), "", "", caFile)
+	assert.Nil(t, err)
+	_, err = handshake(server.ServerConfig(), anonymous.ClientConfig("localhost"))
+	assert.NotNil(t, err)
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	ca := generate(t, 1, "ca", nil)
+	caFile, _ := ca.write(t, dir, "ca")
+	serverCert, serverKey := generate(t, 2, "localhost", ca).write(t, dir, "server")
+
+	server, err := NewReloader(slog.Default(), serverCert, serverKey, "")
+	assert.Nil(t, err)
+	client, err := NewReloader(slog.Default(), "", "", caFile)
+	assert.Nil(t, err)
+
+	serial, err := handshake(server.ServerConfig(), client.ClientConfig("localhost"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), serial)
+
+	generate(t, 4, "localhost", ca).write(t, dir, "server")
+	// Make sure modification time differs on coarse filesystems
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(serverCert, future, future)
+	assert.True(t, server.changed())
+	assert.Nil(t, server.Reload())
+	assert.False(t, server.changed())
+
+	serial, err = handshake(server.ServerConfig(), client.ClientConfig("localhost"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), serial)
+}
+
+func TestReloadKeepsPrevious(t *testing.T) {
+	dir := t.TempDir()
+	ca := generate(t, 1, "ca", nil)
+	serverCert, serverKey := generate(t, 2, "localhost", ca).write(t, dir, "server")
+
+	server, err := NewReloader(slog.Default(), serverCert, serverKey, "")
+	assert.Nil(t, err)
+
+	os.WriteFile(serverCert, []byte("garbage"), 0600)
+	assert.NotNil(t, server.Reload())
+	assert.Equal(t, int64(2), server.certificate().Leaf.SerialNumber.Int64())
+}
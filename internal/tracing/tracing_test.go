@@ -0,0 +1,39 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+)
+
+var ctx = context.Background()
+
+func TestSetupStdout(t *testing.T) {
+	var buf bytes.Buffer
+	shutdown, err := Setup(ctx, Config{
+		ServiceName: "test",
+		Exporter:    ExporterStdout,
+		Writer:      &buf,
+	})
+	assert.Nil(t, err)
+
+	_, span := otel.Tracer("test").Start(ctx, "test-span")
+	span.End()
+
+	assert.Nil(t, shutdown(ctx))
+	assert.Contains(t, buf.String(), "test-span")
+}
+
+func TestSetupNone(t *testing.T) {
+	shutdown, err := Setup(ctx, Config{Exporter: ExporterNone})
+	assert.Nil(t, err)
+	assert.Nil(t, shutdown(ctx))
+}
+
+func TestSetupUnknown(t *testing.T) {
+	_, err := Setup(ctx, Config{Exporter: "zipkin"})
+	assert.ErrorIs(t, err, ErrUnknownExporter)
+}